@@ -0,0 +1,293 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package main
+
+import (
+	"sync"
+	"time"
+	"math/rand"
+	"encoding/json"
+	"glitchyverse/client"
+	"glitchyverse/model"
+	"glitchyverse/protocol"
+)
+
+type bot struct {
+	name     string
+	password string
+	stats    *stats
+	seed     int64
+	client   *client.Client
+	sequence int64 // Of the last movement message, only used by the action loop
+	
+	mutex       sync.Mutex // Protects the following fields, updated by the handlers
+	spaceShip   model.Spaceship
+	roomTypeId  int64
+	ownBuilt    []int64 // Buildings created by the bot, which it can destroy
+	
+	spaceShipReceived chan struct{}
+}
+
+func (b *bot) run(url string, duration time.Duration, interval time.Duration) {
+	var err error
+	b.client, err = client.Dial(url)
+	if err != nil {
+		b.stats.addFailure("dial", err)
+		return
+	}
+	defer b.client.Close()
+	
+	b.spaceShipReceived = make(chan struct{})
+	b.handleMessages()
+	
+	if !b.login() {
+		return
+	}
+	
+	select {
+		case <-b.spaceShipReceived:
+		case <-time.After(b.client.Timeout):
+			b.stats.addFailure("login", client.ErrTimeout)
+			return
+		case <-b.client.Done():
+			b.stats.addFailure("disconnected", b.client.Err())
+			return
+	}
+	
+	rng := rand.New(rand.NewSource(b.seed))
+	actions := []func(rng *rand.Rand){b.move, b.move, b.move, b.build, b.destroy, b.moveItem, b.listSessions}
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		actions[rng.Intn(len(actions))](rng)
+		
+		select {
+			case <-time.After(interval):
+			case <-b.client.Done():
+				b.stats.addFailure("disconnected", b.client.Err())
+				return
+		}
+	}
+}
+
+// Creates the account on the first run, connects to it after
+func (b *bot) login() bool {
+	err := b.call("registerQuery", func() error {
+		return b.client.Register(b.name, b.password, b.name + "'s ship", "")
+	})
+	if protocolErr, ok := err.(*protocol.Error); ok && protocolErr.Code == protocol.ErrorRefused {
+		err = b.call("authAnswer", func() error {
+			return b.client.Auth(b.name, b.password, "")
+		})
+	}
+	if err != nil {
+		b.stats.addFailure("login", err)
+		return false
+	}
+	return true
+}
+
+// Calls a request and records its latency and result
+func (b *bot) call(method string, request func() error) error {
+	start := time.Now()
+	err := request()
+	b.stats.addRequest(method, time.Since(start), err)
+	return err
+}
+
+func (b *bot) handleMessages() {
+	b.client.HandleAll(func(method string, data json.RawMessage) {
+		b.stats.addReceived(method)
+	})
+	
+	b.client.Handle("data_buildingTypesDefinition", func(data json.RawMessage) {
+		var types []model.BuildingType
+		if json.Unmarshal(data, &types) == nil {
+			for _, t := range types {
+				if t.Model == "Room" {
+					b.mutex.Lock()
+					b.roomTypeId = t.Id
+					b.mutex.Unlock()
+				}
+			}
+		}
+	})
+	
+	b.client.Handle("data_spaceship", func(data json.RawMessage) {
+		var spaceShip struct{
+			model.Spaceship
+			Owner bool `json:"owner"`
+		}
+		if json.Unmarshal(data, &spaceShip) == nil && spaceShip.Owner {
+			b.mutex.Lock()
+			first := b.spaceShip.Id == 0
+			b.spaceShip = spaceShip.Spaceship
+			b.mutex.Unlock()
+			
+			if first {
+				close(b.spaceShipReceived)
+			}
+		}
+	})
+	
+	// Corrections of the predicted positions, and the positions of the other spaceships
+	b.client.Handle("updatePosition", func(data json.RawMessage) {
+		var update struct{
+			SpaceshipId int64      `json:"spaceshipId"`
+			Position    model.Vec3 `json:"position"`
+			Rotation    model.Quat `json:"rotation"`
+		}
+		if json.Unmarshal(data, &update) == nil {
+			b.mutex.Lock()
+			if update.SpaceshipId == b.spaceShip.Id {
+				b.spaceShip.Position = update.Position
+				b.spaceShip.Rotation = update.Rotation
+			}
+			b.mutex.Unlock()
+		}
+	})
+	
+	b.client.Handle("addBuilding", func(data json.RawMessage) {
+		var building struct{
+			SpaceshipId int64 `json:"spaceshipId"`
+			model.Building
+		}
+		if json.Unmarshal(data, &building) == nil {
+			b.mutex.Lock()
+			if building.SpaceshipId == b.spaceShip.Id {
+				b.spaceShip.Buildings = append(b.spaceShip.Buildings, building.Building)
+				b.ownBuilt = append(b.ownBuilt, building.Id)
+			}
+			b.mutex.Unlock()
+		}
+	})
+	
+	b.client.Handle("deleteBuilding", func(data json.RawMessage) {
+		var deletion struct{
+			BuildingId  int64 `json:"buildingId"`
+			SpaceshipId int64 `json:"spaceshipId"`
+		}
+		if json.Unmarshal(data, &deletion) == nil {
+			b.mutex.Lock()
+			if deletion.SpaceshipId == b.spaceShip.Id {
+				for i, building := range b.spaceShip.Buildings {
+					if building.Id == deletion.BuildingId {
+						b.spaceShip.Buildings = append(b.spaceShip.Buildings[:i], b.spaceShip.Buildings[i + 1:]...)
+						break
+					}
+				}
+				for i, id := range b.ownBuilt {
+					if id == deletion.BuildingId {
+						b.ownBuilt = append(b.ownBuilt[:i], b.ownBuilt[i + 1:]...)
+						break
+					}
+				}
+			}
+			b.mutex.Unlock()
+		}
+	})
+	
+	// Errors of the requests sent without id (there shouldn't be any)
+	b.client.Handle(protocol.MethodError, func(data json.RawMessage) {
+		protocolErr := &protocol.Error{}
+		if json.Unmarshal(data, protocolErr) == nil {
+			b.stats.addRequest(protocolErr.Method, 0, protocolErr)
+		}
+	})
+}
+
+// Small random move, like a player flying around
+func (b *bot) move(rng *rand.Rand) {
+	b.mutex.Lock()
+	position := b.spaceShip.Position
+	rotation := b.spaceShip.Rotation
+	b.mutex.Unlock()
+	
+	for i := range position {
+		position[i] += rng.Float64() * 2 - 1
+	}
+	b.sequence++
+	b.call("updatePosition", func() error {
+		return b.client.UpdatePosition(position, rotation, b.sequence)
+	})
+}
+
+// Builds a room around the spaceship (it may be refused if it overlaps another building)
+func (b *bot) build(rng *rand.Rand) {
+	b.mutex.Lock()
+	typeId := b.roomTypeId
+	b.mutex.Unlock()
+	if typeId == 0 {
+		return
+	}
+	
+	position := model.Vec3{float64(rng.Intn(40) - 20), 0, float64(rng.Intn(40) - 20)}
+	b.call("buildQuery", func() error {
+		return b.client.Build(typeId, position, model.Vec3{1, 1, 1}, model.Quat{0, 0, 0, 1})
+	})
+}
+
+func (b *bot) destroy(rng *rand.Rand) {
+	b.mutex.Lock()
+	var buildingId int64
+	if len(b.ownBuilt) > 0 {
+		buildingId = b.ownBuilt[rng.Intn(len(b.ownBuilt))]
+	}
+	b.mutex.Unlock()
+	if buildingId == 0 {
+		return
+	}
+	
+	b.call("destroyQuery", func() error {
+		return b.client.Destroy(buildingId)
+	})
+}
+
+// Moves a random item into a random building (it is usually refused)
+func (b *bot) moveItem(rng *rand.Rand) {
+	b.mutex.Lock()
+	var items []model.Item
+	for _, building := range b.spaceShip.Buildings {
+		items = append(items, building.Items...)
+	}
+	var itemId, buildingId int64
+	if len(items) > 0 {
+		itemId = items[rng.Intn(len(items))].Id
+		buildingId = b.spaceShip.Buildings[rng.Intn(len(b.spaceShip.Buildings))].Id
+	}
+	b.mutex.Unlock()
+	if itemId == 0 {
+		return
+	}
+	
+	b.call("moveItemQuery", func() error {
+		return b.client.MoveItem(itemId, buildingId, 0)
+	})
+}
+
+func (b *bot) listSessions(rng *rand.Rand) {
+	b.call("listSessionsQuery", func() error {
+		return b.client.ListSessions()
+	})
+}
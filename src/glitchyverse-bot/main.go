@@ -0,0 +1,77 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+// Spawns scripted bots playing on a server, and reports the latency of their requests,
+// the rate of the received messages and the errors.
+// Usage : glitchyverse-bot -url ws://localhost:8080/play -bots 50 -duration 1m
+package main
+
+import (
+	"os"
+	"fmt"
+	"flag"
+	"sync"
+	"time"
+)
+
+func main() {
+	url      := flag.String  ("url",      "ws://localhost:8080/play", "WebSocket URL of the server")
+	bots     := flag.Int     ("bots",     10,                          "Number of bots")
+	duration := flag.Duration("duration", time.Minute,                 "Playing time of each bot")
+	interval := flag.Duration("interval", 200 * time.Millisecond,      "Delay between two actions of a bot")
+	ramp     := flag.Duration("ramp",     5 * time.Second,             "Time during which the bots are started")
+	prefix   := flag.String  ("prefix",   "bot",                       "Prefix of the bot names (the account is created if it doesn't exist)")
+	password := flag.String  ("password", "botpassword",               "Password of the bot accounts")
+	flag.Parse()
+	
+	if *bots < 1 || len(*prefix) < 3 || len(*password) < 8 {
+		fmt.Fprintln(os.Stderr, "At least one bot, a prefix of 3 characters and a password of 8 characters are required")
+		os.Exit(2)
+	}
+	
+	stats := newStats()
+	var wg sync.WaitGroup
+	
+	fmt.Printf("Starting %d bots on %s for %v ...\n", *bots, *url, *duration)
+	for i := 0 ; i < *bots ; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b := &bot{
+				name:     fmt.Sprintf("%s%d", *prefix, i),
+				password: *password,
+				stats:    stats,
+				seed:     int64(i),
+			}
+			b.run(*url, *duration, *interval)
+		}(i)
+		time.Sleep(*ramp / time.Duration(*bots))
+	}
+	wg.Wait()
+	
+	stats.print(os.Stdout)
+	if stats.hasFailures() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,193 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package main
+
+import (
+	"io"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"text/tabwriter"
+	"glitchyverse/client"
+	"glitchyverse/protocol"
+)
+
+// Results of all the bots
+type stats struct {
+	mutex    sync.Mutex
+	start    time.Time
+	requests map[string]*requestStats // By method
+	received map[string]int           // Messages received by method
+	failures map[string]int           // Bots stopped by a failure, by step
+	examples map[string]string        // First error of each failure step
+}
+
+type requestStats struct {
+	latencies []time.Duration
+	errors    map[string]int // By code
+}
+
+func newStats() *stats {
+	return &stats{
+		start:    time.Now(),
+		requests: make(map[string]*requestStats),
+		received: make(map[string]int),
+		failures: make(map[string]int),
+		examples: make(map[string]string),
+	}
+}
+
+// The latency is 0 for the errors of the requests sent without id
+func (s *stats) addRequest(method string, latency time.Duration, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	r, ok := s.requests[method]
+	if !ok {
+		r = &requestStats{errors: make(map[string]int)}
+		s.requests[method] = r
+	}
+	if latency > 0 {
+		r.latencies = append(r.latencies, latency)
+	}
+	
+	if protocolErr, ok := err.(*protocol.Error); ok {
+		r.errors[protocolErr.Code]++
+	} else if err == client.ErrTimeout {
+		r.errors["timeout"]++
+	} else if err != nil {
+		r.errors["connection"]++
+	}
+}
+
+func (s *stats) addReceived(method string) {
+	s.mutex.Lock()
+	s.received[method]++
+	s.mutex.Unlock()
+}
+
+func (s *stats) addFailure(step string, err error) {
+	s.mutex.Lock()
+	s.failures[step]++
+	if _, ok := s.examples[step]; !ok && err != nil {
+		s.examples[step] = err.Error()
+	}
+	s.mutex.Unlock()
+}
+
+// True if a bot has failed, or if a request got an error which is not a refusal
+func (s *stats) hasFailures() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	if len(s.failures) > 0 {
+		return true
+	}
+	for _, r := range s.requests {
+		for code := range r.errors {
+			if code != protocol.ErrorRefused {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *stats) print(out io.Writer) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	elapsed := time.Since(s.start).Seconds()
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', tabwriter.AlignRight)
+	
+	fmt.Fprintf(out, "\nRequests (%.0fs) :\n", elapsed)
+	fmt.Fprintln(w, "method\tcount\tper second\tp50\tp95\tmax\terrors\t")
+	methods := make([]string, 0, len(s.requests))
+	for method := range s.requests {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		r := s.requests[method]
+		sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%v\t%v\t%v\t%s\t\n",
+			method,
+			len(r.latencies),
+			float64(len(r.latencies)) / elapsed,
+			percentile(r.latencies, 0.50),
+			percentile(r.latencies, 0.95),
+			percentile(r.latencies, 1),
+			formatCounts(r.errors),
+		)
+	}
+	w.Flush()
+	
+	fmt.Fprintln(out, "\nReceived messages :")
+	fmt.Fprintln(w, "method\tcount\tper second\t")
+	for _, method := range sortedKeys(s.received) {
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t\n", method, s.received[method], float64(s.received[method]) / elapsed)
+	}
+	w.Flush()
+	
+	if len(s.failures) > 0 {
+		fmt.Fprintln(out, "\nFailed bots :")
+		for _, step := range sortedKeys(s.failures) {
+			fmt.Fprintf(out, "  %s : %d (%s)\n", step, s.failures[step], s.examples[step])
+		}
+	}
+}
+
+// The latencies must be sorted
+func percentile(latencies []time.Duration, rate float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(latencies) - 1) * rate)
+	return latencies[i].Round(time.Microsecond)
+}
+
+func formatCounts(counts map[string]int) string {
+	result := ""
+	for _, k := range sortedKeys(counts) {
+		if result != "" {
+			result += ", "
+		}
+		result += fmt.Sprintf("%s: %d", k, counts[k])
+	}
+	if result == "" {
+		return "-"
+	}
+	return result
+}
+
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
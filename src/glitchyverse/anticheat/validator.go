@@ -0,0 +1,174 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package anticheat
+
+import (
+	"sync"
+	"time"
+)
+
+// Kinds of violations, found by comparing the movements predicted by the clients with the ones of the server
+const (
+	KindTeleport     = "teleport"     // Position too far to be a prediction error
+	KindAcceleration = "acceleration" // Position which the propellers can't reach
+	KindRotation     = "rotation"     // Rotation which the propellers can't reach
+)
+
+// Responses to the violations, from the mildest
+const (
+	ResponseNone       = "none"       // Only recorded, the prediction is tolerated
+	ResponseRubberBand = "rubberBand" // The client gets back the movement of the server
+	ResponseFreeze     = "freeze"     // The spaceship is stopped, and its propellers can't be used for a while
+	ResponseKick       = "kick"       // The user is disconnected
+)
+
+type Policy struct {
+	TeleportScore     float64 // Added to the score of the user for each violation. 0 = only recorded and rubber-banded.
+	AccelerationScore float64
+	RotationScore     float64
+	DecayPerSecond    float64 // Score forgotten each second
+	RubberBandScore   float64 // Score from which each response is applied
+	FreezeScore       float64 // 0 = never
+	KickScore         float64 // 0 = never
+	FreezeDuration    time.Duration
+}
+
+// The accelerations and rotations score little : the latency variations of honest clients can
+// make them beyond the tolerances once in a while, and these violations must not freeze or kick them.
+var DefaultPolicy = Policy{
+	TeleportScore:     5,
+	AccelerationScore: 1,
+	RotationScore:     1,
+	DecayPerSecond:    0.05,
+	RubberBandScore:   1,
+	FreezeScore:       10,
+	KickScore:         20,
+	FreezeDuration:    30 * time.Second,
+}
+
+const maxEntriesBeforePruning = 10000
+
+// Log of the violations
+type Journal interface {
+	Record(userId int64, kind string, score float64, response string, at time.Time)
+}
+
+type entry struct {
+	score       float64
+	updatedAt   time.Time
+	frozenUntil time.Time
+}
+
+// Scores the movement violations of the users, and chooses the responses.
+// The scores are kept after the disconnections, a kicked user doesn't start again from 0.
+type Validator struct {
+	policy  Policy
+	journal Journal
+	mutex   sync.Mutex
+	entries map[int64]*entry
+}
+
+func NewValidator(policy Policy, journal Journal) *Validator {
+	return &Validator{
+		policy:  policy,
+		journal: journal,
+		entries: make(map[int64]*entry),
+	}
+}
+
+// Records a violation of the user, and returns the response to apply
+func (v *Validator) Violation(userId int64, kind string, now time.Time) (response string) {
+	kindScore := v.kindScore(kind)
+	
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	
+	e, ok := v.entries[userId]
+	if !ok {
+		if len(v.entries) >= maxEntriesBeforePruning {
+			v.prune(now)
+		}
+		e = &entry{updatedAt: now}
+		v.entries[userId] = e
+	}
+	
+	e.score = v.decayedScore(e, now) + kindScore
+	e.updatedAt = now
+	
+	switch {
+		// Not scored : only corrected, even when the score is already high
+		case kindScore == 0:
+			response = ResponseRubberBand
+		case v.policy.KickScore > 0 && e.score >= v.policy.KickScore:
+			response = ResponseKick
+		case v.policy.FreezeScore > 0 && e.score >= v.policy.FreezeScore:
+			response = ResponseFreeze
+			e.frozenUntil = now.Add(v.policy.FreezeDuration)
+		case e.score >= v.policy.RubberBandScore:
+			response = ResponseRubberBand
+		default:
+			response = ResponseNone
+	}
+	
+	v.journal.Record(userId, kind, e.score, response, now)
+	return
+}
+
+// True while the spaceship of the user is frozen
+func (v *Validator) IsFrozen(userId int64, now time.Time) bool {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	
+	e, ok := v.entries[userId]
+	return ok && now.Before(e.frozenUntil)
+}
+
+func (v *Validator) kindScore(kind string) float64 {
+	switch kind {
+		case KindTeleport:
+			return v.policy.TeleportScore
+		case KindAcceleration:
+			return v.policy.AccelerationScore
+		case KindRotation:
+			return v.policy.RotationScore
+	}
+	return 0
+}
+
+func (v *Validator) decayedScore(e *entry, now time.Time) float64 {
+	score := e.score - now.Sub(e.updatedAt).Seconds() * v.policy.DecayPerSecond
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+func (v *Validator) prune(now time.Time) {
+	for userId, e := range v.entries {
+		if v.decayedScore(e, now) == 0 && !now.Before(e.frozenUntil) {
+			delete(v.entries, userId)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package anticheat
+
+import (
+	"testing"
+	"time"
+)
+
+var testStart = time.Unix(1000, 0)
+
+var testPolicy = Policy{
+	TeleportScore:     5,
+	AccelerationScore: 0.5,
+	RotationScore:     0,
+	DecayPerSecond:    0.5,
+	RubberBandScore:   1,
+	FreezeScore:       10,
+	KickScore:         20,
+	FreezeDuration:    30 * time.Second,
+}
+
+type record struct {
+	userId   int64
+	kind     string
+	score    float64
+	response string
+	at       time.Time
+}
+
+type fakeJournal struct {
+	records []record
+}
+
+func (j *fakeJournal) Record(userId int64, kind string, score float64, response string, at time.Time) {
+	j.records = append(j.records, record{userId, kind, score, response, at})
+}
+
+// A violation of the user 1, some seconds after the start
+type violation struct {
+	seconds  float64
+	kind     string
+	response string
+	score    float64 // Recorded
+}
+
+func at(seconds float64) time.Time {
+	return testStart.Add(time.Duration(seconds * float64(time.Second)))
+}
+
+func TestViolation(t *testing.T) {
+	tests := []struct{
+		name       string
+		violations []violation
+	}{
+		{"tolerated", []violation{
+			{0, KindAcceleration, ResponseNone,       0.5},
+			{0, KindAcceleration, ResponseRubberBand, 1  },
+		}},
+		{"not scored", []violation{
+			{0, KindRotation, ResponseRubberBand, 0},
+			{0, "unknown",    ResponseRubberBand, 0},
+		}},
+		{"not scored after a freeze", []violation{
+			{0, KindTeleport, ResponseRubberBand, 5 },
+			{0, KindTeleport, ResponseFreeze,     10},
+			{2, KindRotation, ResponseRubberBand, 9 },
+		}},
+		{"freeze and kick", []violation{
+			{0, KindTeleport, ResponseRubberBand, 5 },
+			{0, KindTeleport, ResponseFreeze,     10},
+			{0, KindTeleport, ResponseFreeze,     15},
+			{0, KindTeleport, ResponseKick,       20},
+		}},
+		{"decay", []violation{
+			{0,  KindTeleport,     ResponseRubberBand, 5  },
+			{4,  KindTeleport,     ResponseRubberBand, 8  },
+			{30, KindAcceleration, ResponseNone,       0.5},
+		}},
+	}
+	
+	for _, test := range tests {
+		journal := &fakeJournal{}
+		v := NewValidator(testPolicy, journal)
+		
+		for i, violation := range test.violations {
+			response := v.Violation(1, violation.kind, at(violation.seconds))
+			if response != violation.response {
+				t.Errorf("%s, violation %d : got response %s, want %s", test.name, i, response, violation.response)
+			}
+			
+			if len(journal.records) != i + 1 {
+				t.Fatalf("%s, violation %d : got %d records, want %d", test.name, i, len(journal.records), i + 1)
+			}
+			want := record{1, violation.kind, violation.score, violation.response, at(violation.seconds)}
+			if got := journal.records[i]; got != want {
+				t.Errorf("%s, violation %d : got record %v, want %v", test.name, i, got, want)
+			}
+		}
+	}
+}
+
+func TestIsFrozen(t *testing.T) {
+	v := NewValidator(testPolicy, &fakeJournal{})
+	v.Violation(1, KindTeleport, at(0))
+	v.Violation(1, KindTeleport, at(0))
+	v.Violation(2, KindTeleport, at(0))
+	
+	tests := []struct{
+		userId  int64
+		seconds float64
+		frozen  bool
+	}{
+		{1, 0,  true },
+		{1, 29, true },
+		{1, 30, false},
+		{2, 0,  false}, // Only rubber-banded
+		{3, 0,  false}, // Unknown
+	}
+	
+	for _, test := range tests {
+		if frozen := v.IsFrozen(test.userId, at(test.seconds)); frozen != test.frozen {
+			t.Errorf("user %d after %vs : got frozen %v, want %v", test.userId, test.seconds, frozen, test.frozen)
+		}
+	}
+}
+
+// The entries are forgotten once their score is back to 0 and their freeze is over
+func TestPrune(t *testing.T) {
+	tests := []struct{
+		seconds float64
+		kept    []int64
+	}{
+		{0,  []int64{1, 2, 3}},
+		{10, []int64{2, 3}   }, // The score of 1 is back to 0
+		{20, []int64{2, 3}   }, // 3 is back to 0 too, but frozen until 30 seconds
+		{30, []int64{2}      }, // 2 is back to 0, but frozen again at 10 seconds
+		{40, []int64{}       },
+	}
+	
+	for _, test := range tests {
+		v := NewValidator(testPolicy, &fakeJournal{})
+		v.Violation(1, KindTeleport, at(0))
+		v.Violation(2, KindTeleport, at(0))
+		v.Violation(2, KindTeleport, at(0))
+		v.Violation(3, KindTeleport, at(0))
+		v.Violation(3, KindTeleport, at(0))
+		v.Violation(2, KindTeleport, at(10))
+		
+		v.prune(at(test.seconds))
+		
+		if len(v.entries) != len(test.kept) {
+			t.Errorf("after %vs : got %d entries, want %v", test.seconds, len(v.entries), test.kept)
+		}
+		for _, userId := range test.kept {
+			if _, ok := v.entries[userId]; !ok {
+				t.Errorf("after %vs : entry of the user %d pruned", test.seconds, userId)
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package client
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"net/http"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"glitchyverse/protocol"
+)
+
+// Client of the /play endpoint, speaking the version 2 of the protocol.
+// The messages of the server are read by a goroutine calling the handlers,
+// which must not call Call themselves (the reply would never be read).
+type Client struct {
+	conn       *websocket.Conn
+	writeMutex sync.Mutex
+	
+	Timeout time.Duration // Maximum time waited by Call for the reply of the server
+	
+	mutex         sync.Mutex // Protects the following fields
+	nextRequestId int64
+	pending       map[int64]chan error // Waiting requests, by id
+	handlers      map[string]func(data json.RawMessage)
+	handleAll     func(method string, data json.RawMessage)
+	err           error // Why the connection has been closed
+	
+	done chan struct{}
+}
+
+var ErrTimeout = errors.New("No reply from the server")
+
+// Connects to a server, for example "ws://localhost:8080/play"
+func Dial(url string) (*Client, error) {
+	dialer := websocket.Dialer{
+		HandshakeTimeout: 10 * time.Second,
+		Subprotocols:     protocol.Subprotocols,
+	}
+	conn, _, err := dialer.Dial(url, http.Header{})
+	if err != nil {
+		return nil, err
+	}
+	if protocol.VersionFromSubprotocol(conn.Subprotocol()) != protocol.VersionEnvelope {
+		conn.Close()
+		return nil, errors.New("The server doesn't support the protocol version 2")
+	}
+	
+	client := &Client{
+		conn:          conn,
+		Timeout:       10 * time.Second,
+		nextRequestId: 1,
+		pending:       make(map[int64]chan error),
+		handlers:      make(map[string]func(data json.RawMessage)),
+		done:          make(chan struct{}),
+	}
+	go client.read()
+	return client, nil
+}
+
+// Sets the function called with the messages of a method (it replaces the previous one).
+// The messages without handler are ignored, except by the HandleAll function.
+func (client *Client) Handle(method string, handler func(data json.RawMessage)) {
+	client.mutex.Lock()
+	client.handlers[method] = handler
+	client.mutex.Unlock()
+}
+
+// Sets a function called with every message of the server, except the replies,
+// before the handler of the method
+func (client *Client) HandleAll(handler func(method string, data json.RawMessage)) {
+	client.mutex.Lock()
+	client.handleAll = handler
+	client.mutex.Unlock()
+}
+
+// Sends a message and waits for the reply of the server.
+// A refused request returns a *protocol.Error.
+func (client *Client) Call(method string, data interface{}) error {
+	reply := make(chan error, 1)
+	
+	client.mutex.Lock()
+	if client.err != nil {
+		client.mutex.Unlock()
+		return client.err
+	}
+	id := client.nextRequestId
+	client.nextRequestId++
+	client.pending[id] = reply
+	client.mutex.Unlock()
+	
+	if err := client.write(id, method, data); err != nil {
+		client.forget(id)
+		return err
+	}
+	
+	select {
+		case err := <-reply:
+			return err
+		case <-time.After(client.Timeout):
+			client.forget(id)
+			return ErrTimeout
+	}
+}
+
+// Sends a message without waiting for a reply. Its errors are received as "error" messages.
+func (client *Client) Send(method string, data interface{}) error {
+	return client.write(0, method, data)
+}
+
+func (client *Client) write(id int64, method string, data interface{}) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	frame, err := json.Marshal(protocol.Envelope{
+		Version: protocol.VersionEnvelope,
+		Id:      id,
+		Method:  method,
+		Data:    json.RawMessage(jsonData),
+	})
+	if err != nil {
+		return err
+	}
+	
+	client.writeMutex.Lock()
+	defer client.writeMutex.Unlock()
+	return client.conn.WriteMessage(websocket.TextMessage, frame)
+}
+
+func (client *Client) forget(id int64) {
+	client.mutex.Lock()
+	delete(client.pending, id)
+	client.mutex.Unlock()
+}
+
+func (client *Client) read() {
+	defer close(client.done)
+	
+	for {
+		_, rawMessage, err := client.conn.ReadMessage()
+		if err != nil {
+			client.fail(err)
+			return
+		}
+		
+		envelope, err := protocol.Decode(protocol.VersionEnvelope, rawMessage)
+		if err != nil {
+			client.fail(err)
+			return
+		}
+		
+		if envelope.ReplyTo != 0 && (envelope.Method == protocol.MethodAck || envelope.Method == protocol.MethodError) {
+			client.reply(envelope)
+			continue
+		}
+		
+		client.mutex.Lock()
+		handleAll := client.handleAll
+		handler := client.handlers[envelope.Method]
+		client.mutex.Unlock()
+		
+		if handleAll != nil {
+			handleAll(envelope.Method, envelope.Data)
+		}
+		if handler != nil {
+			handler(envelope.Data)
+		}
+	}
+}
+
+func (client *Client) reply(envelope protocol.Envelope) {
+	var err error
+	if envelope.Method == protocol.MethodError {
+		protocolErr := &protocol.Error{}
+		if jsonErr := json.Unmarshal(envelope.Data, protocolErr); jsonErr != nil {
+			err = jsonErr
+		} else {
+			err = protocolErr
+		}
+	}
+	
+	client.mutex.Lock()
+	reply, ok := client.pending[envelope.ReplyTo]
+	delete(client.pending, envelope.ReplyTo)
+	client.mutex.Unlock()
+	
+	if ok {
+		reply <- err
+	}
+}
+
+// Closes the connection after an error, and gives it to the waiting requests
+func (client *Client) fail(err error) {
+	client.mutex.Lock()
+	if client.err == nil {
+		client.err = err
+	}
+	pending := client.pending
+	client.pending = make(map[int64]chan error)
+	client.mutex.Unlock()
+	
+	for _, reply := range pending {
+		reply <- err
+	}
+	client.conn.Close()
+}
+
+// Closed when the connection is closed
+func (client *Client) Done() <-chan struct{} {
+	return client.done
+}
+
+// Why the connection has been closed, nil if it is still open
+func (client *Client) Err() error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	return client.err
+}
+
+// Closes the connection normally, and waits until the reading goroutine stops
+func (client *Client) Close() error {
+	client.writeMutex.Lock()
+	err := client.conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second),
+	)
+	client.writeMutex.Unlock()
+	
+	select {
+		case <-client.done:
+		case <-time.After(time.Second):
+			client.conn.Close()
+			<-client.done
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package client
+
+import (
+	"glitchyverse/model"
+)
+
+// Requests of the game, see socket.go for their handling by the server.
+// The definitionsHash can be empty, the server then sends the definitions.
+
+func (client *Client) Auth(name, password, definitionsHash string) error {
+	return client.Call("authAnswer", struct{
+		Name            string `json:"name"`
+		Password        string `json:"password"`
+		DefinitionsHash string `json:"definitionsHash"`
+	}{name, password, definitionsHash})
+}
+
+func (client *Client) ResumeSession(token, definitionsHash string) error {
+	return client.Call("resumeSession", struct{
+		Token           string `json:"token"`
+		DefinitionsHash string `json:"definitionsHash"`
+	}{token, definitionsHash})
+}
+
+func (client *Client) Register(name, password, spaceShipName, definitionsHash string) error {
+	return client.Call("registerQuery", struct{
+		Name            string `json:"name"`
+		Password        string `json:"password"`
+		SpaceshipName   string `json:"spaceshipName"`
+		DefinitionsHash string `json:"definitionsHash"`
+	}{name, password, spaceShipName, definitionsHash})
+}
+
+// The position is a prediction of the client. If it's too far from the position computed by the server,
+// the server sends a correction with an "updatePosition" message, containing the same sequence number.
+// The sequence numbers of the movement messages must increase (0 if not used).
+func (client *Client) UpdatePosition(position model.Vec3, rotation model.Quat, sequence int64) error {
+	return client.Call("updatePosition", struct{
+		Position model.Vec3 `json:"position"`
+		Rotation model.Quat `json:"rotation"`
+		Sequence int64      `json:"sequence"`
+	}{position, rotation, sequence})
+}
+
+func (client *Client) UpdatePropellers(buildingId int64, power float64, sequence int64) error {
+	return client.Call("updatePropellers", struct{
+		Id       int64   `json:"id"`
+		Power    float64 `json:"power"`
+		Sequence int64   `json:"sequence"`
+	}{buildingId, power, sequence})
+}
+
+func (client *Client) UpdateDoors(buildingId int64, state float64) error {
+	return client.Call("updateDoors", struct{
+		Id    int64   `json:"id"`
+		State float64 `json:"state"`
+	}{buildingId, state})
+}
+
+// The new building is received with an "addBuilding" message
+func (client *Client) Build(typeId int64, position model.Vec3, size model.Vec3, rotation model.Quat) error {
+	return client.Call("buildQuery", struct{
+		TypeId   int64      `json:"typeId"`
+		Position model.Vec3 `json:"position"`
+		Size     model.Vec3 `json:"size"`
+		Rotation model.Quat `json:"rotation"`
+	}{typeId, position, size, rotation})
+}
+
+func (client *Client) Destroy(buildingId int64) error {
+	return client.Call("destroyQuery", buildingId)
+}
+
+func (client *Client) MoveItem(itemId int64, buildingId int64, slotGroupId int64) error {
+	return client.Call("moveItemQuery", struct{
+		ItemId      int64 `json:"itemId"`
+		BuildingId  int64 `json:"buildingId"`
+		SlotGroupId int64 `json:"slotGroupId"`
+	}{itemId, buildingId, slotGroupId})
+}
+
+func (client *Client) AchieveBuilding(buildingId int64) error {
+	return client.Call("achieveBuildingQuery", buildingId)
+}
+
+// The sessions are received with a "data_sessions" message
+func (client *Client) ListSessions() error {
+	return client.Call("listSessionsQuery", nil)
+}
@@ -0,0 +1,142 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Source of the current time, which can be replaced by a manual clock in tests
+type Clock interface {
+	Now() time.Time
+	
+	// Sends the time on the timer channel once the duration has passed
+	NewTimer(d time.Duration) Timer
+}
+
+type Timer interface {
+	C() <-chan time.Time
+	
+	// Must be called when the time isn't waited for anymore, so that the clock forgets the timer.
+	// The time may have been sent already.
+	Stop()
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() {
+	t.timer.Stop()
+}
+
+// The system clock
+var Real Clock = realClock{}
+
+// A clock which only moves when it's advanced
+type Manual struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers map[*manualTimer]bool // Waiting timers
+}
+
+type manualTimer struct {
+	clock    *Manual
+	deadline time.Time
+	c        chan time.Time
+}
+
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start, timers: make(map[*manualTimer]bool)}
+}
+
+func (c *Manual) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	return c.now
+}
+
+func (c *Manual) NewTimer(d time.Duration) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	timer := &manualTimer{c, c.now.Add(d), make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+	} else {
+		c.timers[timer] = true
+	}
+	return timer
+}
+
+// Number of timers waiting for their deadline
+func (c *Manual) Waiting() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	return len(c.timers)
+}
+
+// Moves the time forward, firing the timers which have expired
+func (c *Manual) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	
+	c.now = c.now.Add(d)
+	
+	for timer := range c.timers {
+		if !timer.deadline.After(c.now) {
+			timer.c <- c.now // Buffered, never blocks
+			delete(c.timers, timer)
+		}
+	}
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTimer) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	
+	delete(t.clock.timers, t)
+}
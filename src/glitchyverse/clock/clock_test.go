@@ -0,0 +1,110 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var testStart = time.Unix(1000, 0)
+
+func fired(timer Timer) bool {
+	select {
+		case <-timer.C():
+			return true
+		default:
+			return false
+	}
+}
+
+func TestManualAdvance(t *testing.T) {
+	tests := []struct{
+		name    string
+		delay   time.Duration
+		advance []time.Duration
+		fired   bool
+	}{
+		{"not expired",      10 * time.Second, []time.Duration{9 * time.Second},                  false},
+		{"expired",          10 * time.Second, []time.Duration{10 * time.Second},                 true },
+		{"expired in steps", 10 * time.Second, []time.Duration{4 * time.Second, 6 * time.Second}, true },
+		{"no delay",         0,                nil,                                               true },
+	}
+	
+	for _, test := range tests {
+		c := NewManual(testStart)
+		timer := c.NewTimer(test.delay)
+		for _, d := range test.advance {
+			c.Advance(d)
+		}
+		
+		if got := fired(timer); got != test.fired {
+			t.Errorf("%s : got fired %v, want %v", test.name, got, test.fired)
+		}
+		if test.fired && c.Waiting() != 0 {
+			t.Errorf("%s : the fired timer is still waiting", test.name)
+		}
+	}
+}
+
+// The timer sends the time of the advance which expired it
+func TestManualAdvanceTime(t *testing.T) {
+	c := NewManual(testStart)
+	timer := c.NewTimer(time.Second)
+	c.Advance(3 * time.Second)
+	
+	if got, want := <-timer.C(), testStart.Add(3 * time.Second); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := c.Now(), testStart.Add(3 * time.Second); !got.Equal(want) {
+		t.Errorf("now : got %v, want %v", got, want)
+	}
+}
+
+// A stopped timer is forgotten, and never fires
+func TestManualStop(t *testing.T) {
+	c := NewManual(testStart)
+	stopped := c.NewTimer(time.Second)
+	kept := c.NewTimer(time.Second)
+	
+	stopped.Stop()
+	if c.Waiting() != 1 {
+		t.Fatalf("got %d waiting timers, want 1", c.Waiting())
+	}
+	
+	c.Advance(time.Second)
+	if fired(stopped) {
+		t.Error("The stopped timer fired")
+	}
+	if !fired(kept) {
+		t.Error("The other timer didn't fire")
+	}
+	
+	// Stopping a fired timer does nothing
+	kept.Stop()
+	if c.Waiting() != 0 {
+		t.Errorf("got %d waiting timers, want 0", c.Waiting())
+	}
+}
@@ -0,0 +1,162 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package main
+
+import (
+	"os"
+	"fmt"
+	"time"
+	"strconv"
+	"glitchyverse/config"
+	"glitchyverse/content"
+	"glitchyverse/database"
+)
+
+// Runs a command given after the flags, instead of starting the server
+func runCommand(cfg *config.Config) {
+	switch cfg.Command[0] {
+		case "migrate":
+			migrateCommand(cfg, cfg.Command[1:])
+		case "content":
+			contentCommand(cfg, cfg.Command[1:])
+		default:
+			commandUsage("Unknown command : " + cfg.Command[0])
+	}
+}
+
+func commandUsage(message string) {
+	fmt.Println(message)
+	fmt.Println(config.Usage)
+	os.Exit(1)
+}
+
+// migrate up [<version>]   : applies the migrations up to the version (default = all)
+// migrate down [<version>] : reverts the migrations above the version (default = only the last one)
+// migrate status           : lists the migrations
+func migrateCommand(cfg *config.Config, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		commandUsage("Invalid arguments for migrate")
+	}
+	
+	version := -1
+	if len(args) == 2 {
+		var err error
+		if version, err = strconv.Atoi(args[1]); err != nil || version < 0 {
+			commandUsage("Invalid version : " + args[1])
+		}
+	}
+	
+	store := db.OpenSqliteStore(cfg.Database)
+	defer store.Close()
+	
+	switch args[0] {
+		case "up":
+			applied := store.MigrateUp(version, time.Now().Unix())
+			for _, m := range applied {
+				fmt.Printf("Applied  %04d_%s\n", m.Version, m.Name)
+			}
+			if len(applied) == 0 {
+				fmt.Println("Nothing to apply")
+			}
+		case "down":
+			if version < 0 {
+				// Only the last applied migration
+				applied := store.GetAppliedMigrations()
+				last := 0
+				for v := range applied {
+					if v > last {
+						last = v
+					}
+				}
+				version = 0
+				for v := range applied {
+					if v < last && v > version {
+						version = v
+					}
+				}
+			}
+			reverted := store.MigrateDown(version)
+			for _, m := range reverted {
+				fmt.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+			}
+			if len(reverted) == 0 {
+				fmt.Println("Nothing to revert")
+			}
+		case "status":
+			if len(args) != 1 {
+				commandUsage("Invalid arguments for migrate status")
+			}
+			applied := store.GetAppliedMigrations()
+			for _, m := range db.Migrations() {
+				if appliedAt, ok := applied[m.Version]; ok {
+					fmt.Printf("%04d_%-30s applied %s\n", m.Version, m.Name, time.Unix(appliedAt, 0).Format(time.RFC3339))
+				} else {
+					fmt.Printf("%04d_%-30s pending\n", m.Version, m.Name)
+				}
+			}
+		default:
+			commandUsage("Unknown migrate command : " + args[0])
+	}
+}
+
+// content validate [<directory>] : checks a content pack
+// content sync [<directory>]     : checks a content pack and writes it into the database
+func contentCommand(cfg *config.Config, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		commandUsage("Invalid arguments for content")
+	}
+	if args[0] != "validate" && args[0] != "sync" {
+		commandUsage("Unknown content command : " + args[0])
+	}
+	
+	dir := cfg.Content
+	if len(args) == 2 {
+		dir = args[1]
+	}
+	
+	pack, err := content.Load(dir)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	
+	if errs := pack.Validate(); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("The content pack is valid")
+	
+	if args[0] == "sync" {
+		store := db.OpenMigratedSqliteStore(cfg.Database)
+		defer store.Close()
+		
+		for _, warning := range content.Sync(store, pack) {
+			fmt.Println("Warning :", warning)
+		}
+		fmt.Println("The content pack has been written into", cfg.Database)
+	}
+}
@@ -0,0 +1,357 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package config
+
+import (
+	"io"
+	"os"
+	"fmt"
+	"flag"
+	"math"
+	"time"
+	"errors"
+	"strings"
+	"strconv"
+	"reflect"
+	"io/ioutil"
+	"github.com/BurntSushi/toml"
+)
+
+// Prefix of the environment variables overriding the configuration file.
+// The name of a variable is made from the path of the setting in the file,
+// for example GLITCHYVERSE_SPACE_CHUNK_SIZE for chunk_size in the [space] section.
+const EnvironmentPrefix = "GLITCHYVERSE_"
+
+const Usage = "usage: glitchyverse [-c <configuration file>] [-d <database path>] [-w <www directory path>]" +
+	" [-i [<server ip or dns>]:[<port>]] [--double-login <takeover|reject>] [--debug] [--print-config]\n" +
+	"       glitchyverse [<flags>] migrate <up|down|status> [<version>]\n" +
+	"       glitchyverse [<flags>] content <validate|sync> [<content directory>]\n\n" +
+	"Settings are read from the configuration file, then from the " + EnvironmentPrefix + "* environment\n" +
+	"variables, then from the command line.\n" +
+	"Debug mode is not safe and not optimal for a production"
+
+type Config struct {
+	Storage     string `toml:"storage"` // "sqlite", or "memory" to start from the content pack without saving anything
+	Database    string `toml:"database"`
+	Content     string `toml:"content"` // Content pack directory, used by the memory storage and the content command
+	Www         string `toml:"www"`
+	Listen      string `toml:"listen"` // [<server ip or dns>]:[<port>]
+	Debug       bool   `toml:"debug"`
+	DoubleLogin string `toml:"double_login"` // "takeover" or "reject"
+	
+	Space      SpaceConfig      `toml:"space"`
+	Ship       ShipConfig       `toml:"ship"`
+	AntiCheat  AntiCheatConfig  `toml:"anticheat"`
+	Production ProductionConfig `toml:"production"`
+	Sessions   SessionsConfig   `toml:"sessions"`
+	Shutdown   ShutdownConfig   `toml:"shutdown"`
+	
+	Command []string `toml:"-"` // Arguments after the flags, the command to run instead of the server
+}
+
+type SpaceConfig struct {
+	ChunkSize int64 `toml:"chunk_size"` // Must not be changed for an existing database
+	Seed      int16 `toml:"seed"`       // Must not be changed for an existing database
+}
+
+type ShipConfig struct {
+	MaxSpeedPerPropellerUnit float64 `toml:"max_speed_per_propeller_unit"`
+	ThrustPerPropellerUnit   float64 `toml:"thrust_per_propeller_unit"`
+	BuildingUnitMass         float64 `toml:"building_unit_mass"`
+	ItemMass                 float64 `toml:"item_mass"`
+	PositionTolerance        float64 `toml:"position_tolerance"` // Before correcting the position predicted by a client
+	RotationTolerance        float64 `toml:"rotation_tolerance"` // Degrees
+}
+
+type AntiCheatConfig struct {
+	TeleportDistance             float64  `toml:"teleport_distance"`              // Beyond it, a wrong predicted position is a teleport
+	AccelerationTolerance        float64  `toml:"acceleration_tolerance"`         // Beyond the acceleration of the propellers, units/s²
+	AngularAccelerationTolerance float64  `toml:"angular_acceleration_tolerance"` // Degrees/s²
+	TeleportScore                float64  `toml:"teleport_score"`                 // Added to the score of the player for each violation, 0 = only corrected
+	AccelerationScore            float64  `toml:"acceleration_score"`
+	RotationScore                float64  `toml:"rotation_score"`
+	DecayPerSecond               float64  `toml:"decay_per_second"`
+	RubberBandScore              float64  `toml:"rubber_band_score"`              // Scores from which each response is applied
+	FreezeScore                  float64  `toml:"freeze_score"`                   // 0 = never
+	KickScore                    float64  `toml:"kick_score"`                     // 0 = never
+	FreezeDuration               Duration `toml:"freeze_duration"`
+}
+
+type ProductionConfig struct {
+	Delay Duration `toml:"delay"` // Between two ticks of the simulation (item production and movements)
+}
+
+type SessionsConfig struct {
+	TokenLifetime         Duration `toml:"token_lifetime"`
+	LoginAttemptRetention Duration `toml:"login_attempt_retention"` // The older login attempts are deleted when the server starts
+}
+
+type ShutdownConfig struct {
+	Countdown Duration `toml:"countdown"` // Between the warning sent to the clients and their disconnection
+}
+
+// A time.Duration written like "3s" or "720h" in the configuration
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) (err error) {
+	d.Duration, err = time.ParseDuration(string(text))
+	return
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
+func Default() *Config {
+	return &Config{
+		Storage:     "sqlite",
+		Database:    "./glitchyverse.db",
+		Content:     "./content",
+		Www:         "./www",
+		DoubleLogin: "takeover",
+		Space: SpaceConfig{
+			ChunkSize: 100000,
+			Seed:      1234,
+		},
+		Ship: ShipConfig{
+			MaxSpeedPerPropellerUnit: 20,
+			ThrustPerPropellerUnit:   60,
+			BuildingUnitMass:         10,
+			ItemMass:                 1,
+			PositionTolerance:        5,
+			RotationTolerance:        5,
+		},
+		AntiCheat: AntiCheatConfig{
+			TeleportDistance:             50,
+			AccelerationTolerance:        5,
+			AngularAccelerationTolerance: 20,
+			TeleportScore:                5,
+			AccelerationScore:            1,
+			RotationScore:                1,
+			DecayPerSecond:               0.05,
+			RubberBandScore:              1,
+			FreezeScore:                  10,
+			KickScore:                    20,
+			FreezeDuration:               Duration{30 * time.Second},
+		},
+		Production: ProductionConfig{
+			Delay: Duration{3 * time.Second},
+		},
+		Sessions: SessionsConfig{
+			TokenLifetime:         Duration{30 * 24 * time.Hour},
+			LoginAttemptRetention: Duration{30 * 24 * time.Hour},
+		},
+		Shutdown: ShutdownConfig{
+			Countdown: Duration{5 * time.Second},
+		},
+	}
+}
+
+// Reads the configuration from the defaults, the configuration file, the environment
+// (using lookupEnv, like os.LookupEnv) and the command line arguments, in this order.
+// printConfig is true if the configuration must only be printed.
+func Load(args []string, lookupEnv func(string) (string, bool)) (cfg *Config, printConfig bool, err error) {
+	flags := flag.NewFlagSet("glitchyverse", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	
+	configPath  := flags.String("c", "", "")
+	database    := flags.String("d", "", "")
+	www         := flags.String("w", "", "")
+	listen      := flags.String("i", "", "")
+	doubleLogin := flags.String("double-login", "", "")
+	debug       := flags.Bool("debug", false, "")
+	flags.BoolVar(&printConfig, "print-config", false, "")
+	
+	if err = flags.Parse(args); err != nil {
+		return nil, false, err
+	}
+	cfg = Default()
+	cfg.Command = flags.Args()
+	
+	if *configPath == "" {
+		*configPath, _ = lookupEnv(EnvironmentPrefix + "CONFIG")
+	}
+	if *configPath != "" {
+		metaData, err := toml.DecodeFile(*configPath, cfg)
+		if err != nil {
+			return nil, false, err
+		}
+		if undecoded := metaData.Undecoded(); len(undecoded) > 0 {
+			return nil, false, errors.New("Unknown setting in " + *configPath + " : " + undecoded[0].String())
+		}
+	}
+	
+	if err = applyEnvironment(cfg, lookupEnv); err != nil {
+		return nil, false, err
+	}
+	
+	// Only the flags which are on the command line override the other sources
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+			case "d": cfg.Database = *database
+			case "w": cfg.Www = *www
+			case "i": cfg.Listen = *listen
+			case "double-login": cfg.DoubleLogin = *doubleLogin
+			case "debug": cfg.Debug = *debug
+		}
+	})
+	
+	return cfg, printConfig, cfg.Validate()
+}
+
+func (cfg *Config) Validate() error {
+	if cfg.Storage != "sqlite" && cfg.Storage != "memory" {
+		return errors.New("storage must be \"sqlite\" or \"memory\"")
+	}
+	if cfg.Database == "" {
+		return errors.New("The database path can't be empty")
+	}
+	if info, err := os.Stat(cfg.Www); len(cfg.Command) == 0 && (err != nil || !info.IsDir()) {
+		return errors.New("The www directory doesn't exist : " + cfg.Www)
+	}
+	if cfg.DoubleLogin != "takeover" && cfg.DoubleLogin != "reject" {
+		return errors.New("double_login must be \"takeover\" or \"reject\"")
+	}
+	if cfg.Space.ChunkSize <= 0 || cfg.Space.ChunkSize > math.MaxInt32 {
+		return fmt.Errorf("space.chunk_size must be between 1 and %d", math.MaxInt32)
+	}
+	if cfg.Ship.MaxSpeedPerPropellerUnit <= 0 {
+		return errors.New("ship.max_speed_per_propeller_unit must be positive")
+	}
+	if cfg.Ship.ThrustPerPropellerUnit <= 0 {
+		return errors.New("ship.thrust_per_propeller_unit must be positive")
+	}
+	if cfg.Ship.BuildingUnitMass <= 0 {
+		return errors.New("ship.building_unit_mass must be positive")
+	}
+	if cfg.Ship.ItemMass < 0 {
+		return errors.New("ship.item_mass can't be negative")
+	}
+	if cfg.Ship.PositionTolerance < 0 || cfg.Ship.RotationTolerance < 0 {
+		return errors.New("ship.position_tolerance and ship.rotation_tolerance can't be negative")
+	}
+	if cfg.AntiCheat.TeleportDistance < cfg.Ship.PositionTolerance {
+		return errors.New("anticheat.teleport_distance can't be less than ship.position_tolerance")
+	}
+	if cfg.AntiCheat.AccelerationTolerance < 0 || cfg.AntiCheat.AngularAccelerationTolerance < 0 {
+		return errors.New("anticheat.acceleration_tolerance and anticheat.angular_acceleration_tolerance can't be negative")
+	}
+	if a := cfg.AntiCheat; a.TeleportScore < 0 || a.AccelerationScore < 0 || a.RotationScore < 0 || a.DecayPerSecond < 0 ||
+		a.RubberBandScore < 0 || a.FreezeScore < 0 || a.KickScore < 0 {
+		return errors.New("The scores of the anticheat section can't be negative")
+	}
+	if cfg.AntiCheat.FreezeDuration.Duration < 0 {
+		return errors.New("anticheat.freeze_duration can't be negative")
+	}
+	if cfg.Production.Delay.Duration <= 0 {
+		return errors.New("production.delay must be positive")
+	}
+	if cfg.Sessions.TokenLifetime.Duration <= 0 {
+		return errors.New("sessions.token_lifetime must be positive")
+	}
+	if cfg.Sessions.LoginAttemptRetention.Duration <= 0 {
+		return errors.New("sessions.login_attempt_retention must be positive")
+	}
+	if cfg.Shutdown.Countdown.Duration < 0 {
+		return errors.New("shutdown.countdown can't be negative")
+	}
+	return nil
+}
+
+// Writes the configuration in the format of the configuration file
+func (cfg *Config) Print(w io.Writer) error {
+	return toml.NewEncoder(w).Encode(cfg)
+}
+
+var durationType = reflect.TypeOf(Duration{})
+
+func applyEnvironment(cfg *Config, lookupEnv func(string) (string, bool)) error {
+	return walkSettings(reflect.ValueOf(cfg).Elem(), "", func(path string, field reflect.Value) error {
+		name := EnvironmentPrefix + strings.ToUpper(strings.Replace(path, ".", "_", -1))
+		if value, ok := lookupEnv(name); ok {
+			if err := setSetting(field, value); err != nil {
+				return errors.New("Invalid value for " + name + " : " + err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+// Calls the callback for each setting (= leaf field of the configuration), with its path ("space.chunk_size")
+func walkSettings(value reflect.Value, prefix string, callBack func(path string, field reflect.Value) error) error {
+	for i := 0 ; i < value.NumField() ; i++ {
+		tag := value.Type().Field(i).Tag.Get("toml")
+		if tag == "-" {
+			continue
+		}
+		path := prefix + tag
+		field := value.Field(i)
+		
+		var err error
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			err = walkSettings(field, path + ".", callBack)
+		} else {
+			err = callBack(path, field)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setSetting(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		return field.Addr().Interface().(*Duration).UnmarshalText([]byte(value))
+	}
+	
+	switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			field.SetBool(b)
+		case reflect.Int16, reflect.Int32, reflect.Int64:
+			i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+			if err != nil {
+				return err
+			}
+			field.SetInt(i)
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			field.SetFloat(f)
+		default:
+			return errors.New("Unsupported setting type " + field.Type().String())
+	}
+	return nil
+}
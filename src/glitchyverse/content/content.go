@@ -0,0 +1,137 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+// Definitions of the game content (building types, items ...), stored as JSON files
+// so that they can be reviewed, and synchronized into the database.
+package content
+
+import (
+	"os"
+	"errors"
+	"path/filepath"
+	"encoding/json"
+)
+
+// Files of a content pack directory, each one containing a JSON array
+const (
+	CategoriesFile    = "building_type_categories.json"
+	ItemGroupsFile    = "item_groups.json"
+	ItemTypesFile     = "item_types.json"
+	BuildingTypesFile = "building_types.json"
+	BodyTypesFile     = "body_types.json"
+)
+
+// Id of the group containing all item types
+const AnyItemGroupId = 0
+
+type Pack struct {
+	Categories    []Category
+	ItemGroups    []ItemGroup
+	ItemTypes     []ItemType
+	BuildingTypes []BuildingType
+	BodyTypes     []BodyType
+}
+
+type Category struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type ItemGroup struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type ItemType struct {
+	Id       int64    `json:"id"`
+	Name     string   `json:"name"`
+	MaxState float64  `json:"maxState"`
+	Groups   []string `json:"groups,omitempty"` // Names of the groups, the "any" group is implicit
+}
+
+type BuildingType struct {
+	Id                   int64      `json:"id"`
+	Name                 string     `json:"name"`
+	Category             string     `json:"category,omitempty"` // Name of the category, empty if it can't be built by the players
+	Model                string     `json:"model"`
+	IsGap                bool       `json:"isGap"`
+	IsPositionByRoomUnit bool       `json:"isPositionByRoomUnit"`
+	DefaultState         *float64   `json:"defaultState,omitempty"` // The states are all nil for the types without state
+	MinState             *float64   `json:"minState,omitempty"`
+	MaxState             *float64   `json:"maxState,omitempty"`
+	IsSizeable           bool       `json:"isSizeable"`
+	IsContainer          bool       `json:"isContainer"`
+	IsInside             *bool      `json:"isInside,omitempty"` // nil if it can be inside or outside
+	CanExertThrust       bool       `json:"canExertThrust"`
+	IsControllable       bool       `json:"isControllable"`
+	Slots                []ItemSlot `json:"slots,omitempty"`
+}
+
+type ItemSlot struct {
+	Group          string  `json:"group"`          // Name of the item group
+	WhenBuilding   bool    `json:"whenBuilding"`   // Items required to build, instead of items used by the built building
+	MaximumAmount  int64   `json:"maximumAmount"`  // Per room unit of the building
+	StateVariation float64 `json:"stateVariation"` // Per second and per room unit, negative for consumption
+}
+
+type BodyType struct {
+	Id                    int64   `json:"id"`
+	Name                  string  `json:"name"`
+	Model                 string  `json:"model"`
+	MaxVisibilityDistance float64 `json:"maxVisibilityDistance"`
+}
+
+// Reads the content pack of a directory. Unknown fields are refused to detect typos.
+func Load(dir string) (*Pack, error) {
+	pack := &Pack{}
+	
+	files := []struct{
+		name   string
+		target interface{}
+	}{
+		{CategoriesFile,    &pack.Categories   },
+		{ItemGroupsFile,    &pack.ItemGroups   },
+		{ItemTypesFile,     &pack.ItemTypes    },
+		{BuildingTypesFile, &pack.BuildingTypes},
+		{BodyTypesFile,     &pack.BodyTypes    },
+	}
+	
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		
+		decoder := json.NewDecoder(f)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(file.target)
+		f.Close()
+		if err != nil {
+			return nil, errors.New(path + " : " + err.Error())
+		}
+	}
+	
+	return pack, nil
+}
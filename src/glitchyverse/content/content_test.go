@@ -0,0 +1,99 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package content
+
+import (
+	"testing"
+)
+
+// Content pack of the repository
+const packDir = "../../../content"
+
+func loadPack(t *testing.T) *Pack {
+	pack, err := Load(packDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := pack.Validate(); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	return pack
+}
+
+func TestNewMemoryStore(t *testing.T) {
+	pack := loadPack(t)
+	store := NewMemoryStore(pack)
+	
+	buildingTypes := store.GetBuildingTypes()
+	if len(buildingTypes) != len(pack.BuildingTypes) {
+		t.Fatalf("got %d building types, want %d", len(buildingTypes), len(pack.BuildingTypes))
+	}
+	for i, bt := range buildingTypes {
+		want := pack.BuildingTypes[i]
+		if bt.Id != want.Id || bt.Name != want.Name || bt.Model != want.Model || bt.CanExertThrust != want.CanExertThrust {
+			t.Errorf("building type %d : got %+v, want %+v", i, bt, want)
+		}
+		if (bt.Category == nil) != (want.Category == "") || (bt.Category != nil && *bt.Category != want.Category) {
+			t.Errorf("building type %q : got category %v, want %q", bt.Name, bt.Category, want.Category)
+		}
+	}
+	
+	slotCount := 0
+	for _, bt := range pack.BuildingTypes {
+		slotCount += len(bt.Slots)
+	}
+	if slots := store.GetItemSlots(); len(slots) != slotCount {
+		t.Errorf("got %d item slots, want %d", len(slots), slotCount)
+	}
+	
+	bodyTypes := 0
+	store.GetBodyTypes(func(id int64, name string, model string, maxVisibilityDistance float64) {
+		bodyTypes++
+		if maxVisibilityDistance <= 0 {
+			t.Errorf("body type %q : maxVisibilityDistance %v", name, maxVisibilityDistance)
+		}
+	})
+	if bodyTypes != len(pack.BodyTypes) {
+		t.Errorf("got %d body types, want %d", bodyTypes, len(pack.BodyTypes))
+	}
+	
+	// Synchronizing the same pack again changes nothing
+	if warnings := Sync(store, pack); len(warnings) > 0 {
+		t.Errorf("unexpected warnings : %v", warnings)
+	}
+	if again := store.GetBuildingTypes(); len(again) != len(buildingTypes) {
+		t.Errorf("got %d building types after a second sync, want %d", len(again), len(buildingTypes))
+	}
+}
+
+func TestSyncWarnsAboutMissingDefinitions(t *testing.T) {
+	pack := loadPack(t)
+	store := NewMemoryStore(pack)
+	
+	pack.BodyTypes = pack.BodyTypes[1:]
+	if warnings := Sync(store, pack); len(warnings) != 1 {
+		t.Fatalf("got warnings %v, want one for the removed body type", warnings)
+	}
+}
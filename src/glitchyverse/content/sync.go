@@ -0,0 +1,174 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package content
+
+import (
+	"fmt"
+	"glitchyverse/database"
+)
+
+// Stores whose definitions can be written by Sync
+type Store interface {
+	db.Store
+	UpsertBuildingTypeCategory(id int64, name string)
+	UpsertItemGroup(id int64, name string)
+	UpsertItemType(id int64, name string, maxState float64)
+	SetItemTypeGroups(typeId int64, groupIds []int64)
+	UpsertBuildingType(
+		id int64,
+		categoryId int64,
+		name string,
+		model string,
+		isGap bool,
+		isPositionByRoomUnit bool,
+		defaultState *float64,
+		minState *float64,
+		maxState *float64,
+		isSizeable bool,
+		isContainer bool,
+		isInside *bool,
+		canExertThrust bool,
+		isControllable bool,
+	)
+	DeleteItemSlots(buildingTypeId int64)
+	InsertItemSlot(buildingTypeId int64, itemGroupId int64, whenBuilding bool, maxAmount int64, variation float64)
+	UpsertBodyType(id int64, name string, model string, maxVisibilityDistance float64)
+}
+
+var (
+	_ Store = (*db.SqliteStore)(nil)
+	_ Store = (*db.MemoryStore)(nil)
+)
+
+// Returns a memory store containing only the definitions of a valid content pack
+func NewMemoryStore(pack *Pack) *db.MemoryStore {
+	store := db.NewMemoryStore()
+	Sync(store, pack)
+	return store
+}
+
+// Writes a valid content pack into the database, in one transaction.
+// The definitions of the database missing in the pack are kept, because buildings
+// and items may still use them, and are returned as warnings.
+func Sync(store Store, pack *Pack) (warnings []string) {
+	categoryIds := make(map[string]int64)
+	for _, c := range pack.Categories {
+		categoryIds[c.Name] = c.Id
+	}
+	groupIds := make(map[string]int64)
+	for _, g := range pack.ItemGroups {
+		groupIds[g.Name] = g.Id
+	}
+	
+	store.DeferredTransaction(func() bool {
+		for _, c := range pack.Categories {
+			store.UpsertBuildingTypeCategory(c.Id, c.Name)
+		}
+		
+		for _, g := range pack.ItemGroups {
+			store.UpsertItemGroup(g.Id, g.Name)
+		}
+		
+		for _, t := range pack.ItemTypes {
+			store.UpsertItemType(t.Id, t.Name, t.MaxState)
+			
+			typeGroupIds := []int64{AnyItemGroupId}
+			for _, group := range t.Groups {
+				if groupIds[group] != AnyItemGroupId {
+					typeGroupIds = append(typeGroupIds, groupIds[group])
+				}
+			}
+			store.SetItemTypeGroups(t.Id, typeGroupIds)
+		}
+		
+		for _, t := range pack.BuildingTypes {
+			store.UpsertBuildingType(
+				t.Id,
+				categoryIds[t.Category], // 0 (NULL) if there is no category
+				t.Name,
+				t.Model,
+				t.IsGap,
+				t.IsPositionByRoomUnit,
+				t.DefaultState,
+				t.MinState,
+				t.MaxState,
+				t.IsSizeable,
+				t.IsContainer,
+				t.IsInside,
+				t.CanExertThrust,
+				t.IsControllable,
+			)
+			
+			store.DeleteItemSlots(t.Id)
+			for _, slot := range t.Slots {
+				store.InsertItemSlot(t.Id, groupIds[slot.Group], slot.WhenBuilding, slot.MaximumAmount, slot.StateVariation)
+			}
+		}
+		
+		for _, t := range pack.BodyTypes {
+			store.UpsertBodyType(t.Id, t.Name, t.Model, t.MaxVisibilityDistance)
+		}
+		
+		return true
+	})
+	
+	// Looking for the definitions which are not in the pack
+	inPack := make(map[string]bool)
+	for _, g := range pack.ItemGroups {
+		inPack[fmt.Sprint("group", g.Id)] = true
+	}
+	for _, t := range pack.ItemTypes {
+		inPack[fmt.Sprint("item", t.Id)] = true
+	}
+	for _, t := range pack.BuildingTypes {
+		inPack[fmt.Sprint("building", t.Id)] = true
+	}
+	for _, t := range pack.BodyTypes {
+		inPack[fmt.Sprint("body", t.Id)] = true
+	}
+	
+	store.GetItemGroups(func(id int64, name string) {
+		if !inPack[fmt.Sprint("group", id)] {
+			warnings = append(warnings, fmt.Sprintf("The item group %d (%s) is not in the content pack", id, name))
+		}
+	})
+	store.GetItemTypes(func(id int64, name string, maxState float64) {
+		if !inPack[fmt.Sprint("item", id)] {
+			warnings = append(warnings, fmt.Sprintf("The item type %d (%s) is not in the content pack", id, name))
+		}
+	})
+	for _, t := range store.GetBuildingTypes() {
+		if !inPack[fmt.Sprint("building", t.Id)] {
+			warnings = append(warnings, fmt.Sprintf("The building type %d (%s) is not in the content pack", t.Id, t.Name))
+		}
+	}
+	store.GetBodyTypes(func(id int64, name string, model string, maxVisibilityDistance float64) {
+		if !inPack[fmt.Sprint("body", id)] {
+			warnings = append(warnings, fmt.Sprintf("The body type %d (%s) is not in the content pack", id, name))
+		}
+	})
+	
+	return
+}
@@ -0,0 +1,175 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package content
+
+import (
+	"fmt"
+)
+
+// Returns all the problems of the content pack (nil if it's valid)
+func (pack *Pack) Validate() (errs []error) {
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	
+	categories := make(map[string]bool)
+	categoryIds := make(map[int64]bool)
+	for _, c := range pack.Categories {
+		if c.Id <= 0 {
+			fail("Category %q : the id must be positive", c.Name)
+		}
+		if categoryIds[c.Id] {
+			fail("Category %q : duplicated id %d", c.Name, c.Id)
+		}
+		if c.Name == "" || categories[c.Name] {
+			fail("Category %d : the name must be unique and not empty", c.Id)
+		}
+		categoryIds[c.Id] = true
+		categories[c.Name] = true
+	}
+	
+	groups := make(map[string]bool)
+	groupIds := make(map[int64]bool)
+	for _, g := range pack.ItemGroups {
+		if g.Id < 0 {
+			fail("Item group %q : the id can't be negative", g.Name)
+		}
+		if groupIds[g.Id] {
+			fail("Item group %q : duplicated id %d", g.Name, g.Id)
+		}
+		if g.Name == "" || groups[g.Name] {
+			fail("Item group %d : the name must be unique and not empty", g.Id)
+		}
+		groupIds[g.Id] = true
+		groups[g.Name] = true
+	}
+	if !groupIds[AnyItemGroupId] {
+		fail("The item group %d (containing all the item types) is missing", AnyItemGroupId)
+	}
+	
+	itemTypeNames := make(map[string]bool)
+	itemTypeIds := make(map[int64]bool)
+	for _, t := range pack.ItemTypes {
+		if t.Id <= 0 {
+			fail("Item type %q : the id must be positive", t.Name)
+		}
+		if itemTypeIds[t.Id] {
+			fail("Item type %q : duplicated id %d", t.Name, t.Id)
+		}
+		if t.Name == "" || itemTypeNames[t.Name] {
+			fail("Item type %d : the name must be unique and not empty", t.Id)
+		}
+		if t.MaxState < 0 {
+			fail("Item type %q : maxState can't be negative", t.Name)
+		}
+		for _, group := range t.Groups {
+			if !groups[group] {
+				fail("Item type %q : unknown group %q", t.Name, group)
+			}
+		}
+		itemTypeIds[t.Id] = true
+		itemTypeNames[t.Name] = true
+	}
+	
+	buildingTypeNames := make(map[string]bool)
+	buildingTypeIds := make(map[int64]bool)
+	for _, t := range pack.BuildingTypes {
+		if t.Id <= 0 {
+			fail("Building type %q : the id must be positive", t.Name)
+		}
+		if buildingTypeIds[t.Id] {
+			fail("Building type %q : duplicated id %d", t.Name, t.Id)
+		}
+		if t.Name == "" || buildingTypeNames[t.Name] {
+			fail("Building type %d : the name must be unique and not empty", t.Id)
+		}
+		if t.Category != "" && !categories[t.Category] {
+			fail("Building type %q : unknown category %q", t.Name, t.Category)
+		}
+		if t.Model == "" {
+			fail("Building type %q : the model can't be empty", t.Name)
+		}
+		
+		// States are all defined, or none of them
+		if t.DefaultState != nil || t.MinState != nil || t.MaxState != nil {
+			if t.DefaultState == nil || t.MinState == nil || t.MaxState == nil {
+				fail("Building type %q : defaultState, minState and maxState must be all defined or all empty", t.Name)
+			} else if *t.MinState >= *t.MaxState || *t.DefaultState < *t.MinState || *t.DefaultState > *t.MaxState {
+				fail("Building type %q : the states must verify minState <= defaultState <= maxState and minState < maxState", t.Name)
+			}
+		}
+		if t.CanExertThrust && t.MaxState == nil {
+			fail("Building type %q : a building exerting thrust must have states", t.Name)
+		}
+		if t.IsContainer && (t.IsInside == nil || *t.IsInside) {
+			fail("Building type %q : a container must be outside (isInside = false)", t.Name)
+		}
+		
+		slots := make(map[string]bool)
+		for _, slot := range t.Slots {
+			if !groups[slot.Group] {
+				fail("Building type %q : unknown item group %q in slots", t.Name, slot.Group)
+			}
+			key := fmt.Sprint(slot.Group, slot.WhenBuilding)
+			if slots[key] {
+				fail("Building type %q : duplicated slot for the group %q", t.Name, slot.Group)
+			}
+			if slot.MaximumAmount <= 0 {
+				fail("Building type %q : the maximumAmount of the slots must be positive", t.Name)
+			}
+			if slot.WhenBuilding && slot.StateVariation != 0 {
+				fail("Building type %q : the items required to build can't vary", t.Name)
+			}
+			slots[key] = true
+		}
+		
+		buildingTypeIds[t.Id] = true
+		buildingTypeNames[t.Name] = true
+	}
+	
+	bodyTypeNames := make(map[string]bool)
+	bodyTypeIds := make(map[int64]bool)
+	for _, t := range pack.BodyTypes {
+		if t.Id <= 0 {
+			fail("Body type %q : the id must be positive", t.Name)
+		}
+		if bodyTypeIds[t.Id] {
+			fail("Body type %q : duplicated id %d", t.Name, t.Id)
+		}
+		if t.Name == "" || bodyTypeNames[t.Name] {
+			fail("Body type %d : the name must be unique and not empty", t.Id)
+		}
+		if t.Model == "" {
+			fail("Body type %q : the model can't be empty", t.Name)
+		}
+		if t.MaxVisibilityDistance <= 0 {
+			fail("Body type %q : maxVisibilityDistance must be positive", t.Name)
+		}
+		bodyTypeIds[t.Id] = true
+		bodyTypeNames[t.Name] = true
+	}
+	
+	return
+}
@@ -0,0 +1,271 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+)
+
+// Functions writing the definitions of the game content (see the content package).
+// They must be called inside a transaction.
+
+// Runs the update query, then the insert query if no row has been updated
+func (store *SqliteStore) upsert(updateQuery string, insertQuery string, args ...interface{}) {
+	changes, err := store.conn.ExecDml(updateQuery, args...)
+	if err != nil {
+		log.Panic(err)
+	}
+	if changes == 0 {
+		err = store.conn.Exec(insertQuery, args...)
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+}
+
+func (store *SqliteStore) UpsertBuildingTypeCategory(id int64, name string) {
+	store.upsert(`
+		UPDATE building_type_category
+		SET building_type_category_name = ?2
+		WHERE building_type_category_id = ?1
+		;
+	`, `
+		INSERT INTO building_type_category (
+			building_type_category_id,
+			building_type_category_name
+		) VALUES (
+			?1,
+			?2
+		);
+	`, id, name)
+}
+
+func (store *SqliteStore) UpsertItemGroup(id int64, name string) {
+	store.upsert(`
+		UPDATE item_group
+		SET item_group_name = ?2
+		WHERE item_group_id = ?1
+		;
+	`, `
+		INSERT INTO item_group (
+			item_group_id,
+			item_group_name
+		) VALUES (
+			?1,
+			?2
+		);
+	`, id, name)
+}
+
+// The groups of the item type must be set after with SetItemTypeGroups
+func (store *SqliteStore) UpsertItemType(id int64, name string, maxState float64) {
+	store.upsert(`
+		UPDATE item_type
+		SET
+			item_type_name = ?2,
+			item_type_max_state = ?3
+		WHERE item_type_id = ?1
+		;
+	`, `
+		INSERT INTO item_type (
+			item_type_id,
+			item_type_name,
+			item_type_max_state
+		) VALUES (
+			?1,
+			?2,
+			?3
+		);
+	`, id, name, maxState)
+}
+
+// Replaces the groups of an item type
+func (store *SqliteStore) SetItemTypeGroups(typeId int64, groupIds []int64) {
+	err := store.conn.Exec(`
+		DELETE FROM item_type_in_item_group
+		WHERE item_type_id = ?1
+		;
+	`, typeId)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	for _, groupId := range groupIds {
+		err = store.conn.Exec(`
+			INSERT INTO item_type_in_item_group (
+				item_type_id,
+				item_group_id
+			) VALUES (
+				?1,
+				?2
+			);
+		`, typeId, groupId)
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+}
+
+// categoryId <= 0 --> NULL (can't be built by the players). The nil states are saved as NULL.
+func (store *SqliteStore) UpsertBuildingType(
+	id int64,
+	categoryId int64,
+	name string,
+	model string,
+	isGap bool,
+	isPositionByRoomUnit bool,
+	defaultState *float64,
+	minState *float64,
+	maxState *float64,
+	isSizeable bool,
+	isContainer bool,
+	isInside *bool,
+	canExertThrust bool,
+	isControllable bool,
+) {
+	store.upsert(`
+		UPDATE building_type
+		SET
+			building_type_category_id = ?2,
+			building_type_name = ?3,
+			building_type_model = ?4,
+			building_type_is_gap = ?5,
+			building_type_is_position_by_room_unit = ?6,
+			building_type_default_state = ?7,
+			building_type_min_state = ?8,
+			building_type_max_state = ?9,
+			building_type_is_sizeable = ?10,
+			building_type_is_container = ?11,
+			building_type_is_inside = ?12,
+			building_type_can_exert_thrust = ?13,
+			building_type_is_controllable = ?14
+		WHERE building_type_id = ?1
+		;
+	`, `
+		INSERT INTO building_type (
+			building_type_id,
+			building_type_category_id,
+			building_type_name,
+			building_type_model,
+			building_type_is_gap,
+			building_type_is_position_by_room_unit,
+			building_type_default_state,
+			building_type_min_state,
+			building_type_max_state,
+			building_type_is_sizeable,
+			building_type_is_container,
+			building_type_is_inside,
+			building_type_can_exert_thrust,
+			building_type_is_controllable
+		) VALUES (
+			?1,
+			?2,
+			?3,
+			?4,
+			?5,
+			?6,
+			?7,
+			?8,
+			?9,
+			?10,
+			?11,
+			?12,
+			?13,
+			?14
+		);
+	`,
+		id,
+		int64ToNull(categoryId),
+		name,
+		model,
+		isGap,
+		isPositionByRoomUnit,
+		float64PtrToNull(defaultState),
+		float64PtrToNull(minState),
+		float64PtrToNull(maxState),
+		isSizeable,
+		isContainer,
+		boolPtrToNull(isInside),
+		canExertThrust,
+		isControllable,
+	)
+}
+
+// Removes all the item slots of a building type, before inserting the new ones
+func (store *SqliteStore) DeleteItemSlots(buildingTypeId int64) {
+	err := store.conn.Exec(`
+		DELETE FROM item_slot
+		WHERE building_type_id = ?1
+		;
+	`, buildingTypeId)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+func (store *SqliteStore) InsertItemSlot(buildingTypeId int64, itemGroupId int64, whenBuilding bool, maxAmount int64, variation float64) {
+	err := store.conn.Exec(`
+		INSERT INTO item_slot (
+			building_type_id,
+			item_group_id,
+			item_slot_when_building,
+			item_slot_maximum_amount,
+			item_slot_state_variation
+		) VALUES (
+			?1,
+			?2,
+			?3,
+			?4,
+			?5
+		);
+	`, buildingTypeId, itemGroupId, whenBuilding, maxAmount, variation)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+func (store *SqliteStore) UpsertBodyType(id int64, name string, model string, maxVisibilityDistance float64) {
+	store.upsert(`
+		UPDATE body_type
+		SET
+			body_type_name = ?2,
+			body_type_model = ?3,
+			body_type_max_visibility_distance = ?4
+		WHERE body_type_id = ?1
+		;
+	`, `
+		INSERT INTO body_type (
+			body_type_id,
+			body_type_name,
+			body_type_model,
+			body_type_max_visibility_distance
+		) VALUES (
+			?1,
+			?2,
+			?3,
+			?4
+		);
+	`, id, name, model, maxVisibilityDistance)
+}
@@ -29,8 +29,8 @@ import (
 )
 
 // Returns false if the building deletion hasn't been allowed
-func DeleteBuilding(spaceShipId int64, buildingId int64) bool {
-	changes, err := db.ExecDml(`
+func (store *SqliteStore) DeleteBuilding(spaceShipId int64, buildingId int64) bool {
+	changes, err := store.conn.ExecDml(`
 		DELETE FROM building
 		WHERE building_id = ?2
 		AND spaceship_id = ?1
@@ -29,8 +29,8 @@ import (
 )
 
 // Removes all items from a building inventory
-func DeleteItems(spaceShipId int64, buildingId int64) {
-	err := db.Exec(`
+func (store *SqliteStore) DeleteItems(spaceShipId int64, buildingId int64) {
+	err := store.conn.Exec(`
 		DELETE FROM item
 		WHERE building_id = ?2
 		AND building_id IN (
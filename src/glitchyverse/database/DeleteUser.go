@@ -0,0 +1,67 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+)
+
+// Deletes a user and everything it owns. Must be called inside a transaction.
+func (store *SqliteStore) DeleteUser(userId int64) {
+	queries := []string{
+		`
+			DELETE FROM item
+			WHERE building_id IN (
+				SELECT building_id
+				FROM building
+				NATURAL INNER JOIN spaceship
+				WHERE spaceship.user_id = ?1
+			);
+		`, `
+			DELETE FROM building
+			WHERE spaceship_id IN (
+				SELECT spaceship_id
+				FROM spaceship
+				WHERE user_id = ?1
+			);
+		`, `
+			DELETE FROM session
+			WHERE user_id = ?1;
+		`, `
+			DELETE FROM spaceship
+			WHERE user_id = ?1;
+		`, `
+			DELETE FROM user
+			WHERE user_id = ?1;
+		`,
+	}
+	
+	for _, query := range queries {
+		err := store.conn.Exec(query, userId)
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"glitchyverse/model"
+)
+
+// Functions using the current store, see Store for their documentation.
+// Each call waits for the running transaction, see DeferredTransaction.
+
+func GetUserCredentials(name string) (userId int64, passwordHash string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetUserCredentials(name)
+}
+
+func InsertUser(name string, passwordHash string) (bool, int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.InsertUser(name, passwordHash)
+}
+
+func SetUserPassword(userId int64, passwordHash string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.SetUserPassword(userId, passwordHash)
+}
+
+func DeleteUser(userId int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.DeleteUser(userId)
+}
+
+func InsertSession(userId int64, tokenHash string, label string, now int64, expiresAt int64) int64 {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.InsertSession(userId, tokenHash, label, now, expiresAt)
+}
+
+func UseSession(tokenHash string, now int64) (sessionId int64, userId int64, userName string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.UseSession(tokenHash, now)
+}
+
+func GetSessions(userId int64, now int64, rowHandler func(
+	id int64,
+	label string,
+	createdAt int64,
+	lastUsedAt int64,
+	expiresAt int64,
+)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.GetSessions(userId, now, rowHandler)
+}
+
+func RevokeSession(userId int64, sessionId int64) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.RevokeSession(userId, sessionId)
+}
+
+func RevokeSessions(userId int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.RevokeSessions(userId)
+}
+
+func DeleteExpiredSessions(now int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.DeleteExpiredSessions(now)
+}
+
+func InsertLoginAttempt(userName string, ip string, result string, at int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.InsertLoginAttempt(userName, ip, result, at)
+}
+
+func GetLoginLockout(userName string) (until int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetLoginLockout(userName)
+}
+
+func SetLoginLockout(userName string, until int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.SetLoginLockout(userName, until)
+}
+
+func DeleteLoginAudit(before int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.DeleteLoginAudit(before)
+}
+
+func InsertMovementIncident(userId int64, kind string, score float64, response string, at int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.InsertMovementIncident(userId, kind, score, response, at)
+}
+
+func InsertUserOnline(userId int64, spaceShipId int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.InsertUserOnline(userId, spaceShipId)
+}
+
+func DeleteUserOnline(userId int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.DeleteUserOnline(userId)
+}
+
+func GetOnlineSpaceShipIds() []int64 {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetOnlineSpaceShipIds()
+}
+
+func GetFirstSpaceShipId(userId int64) (spaceShipId int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetFirstSpaceShipId(userId)
+}
+
+func GetSpaceShip(spaceShipId int64) (spaceShip model.Spaceship, found bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetSpaceShip(spaceShipId)
+}
+
+func InsertSpaceShip(userId int64, name string) (bool, int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.InsertSpaceShip(userId, name)
+}
+
+func SetSpaceShipMotion(spaceShipId int64, position model.Vec3, rotation model.Quat, velocity, angularVelocity model.Vec3) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.SetSpaceShipMotion(spaceShipId, position, rotation, velocity, angularVelocity)
+}
+
+func GetBuildings(spaceShipId int64, buildingId int64) []model.Building {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetBuildings(spaceShipId, buildingId)
+}
+
+func InsertBuilding(
+	spaceShipId int64,
+	typeId int64,
+	position model.Vec3,
+	size model.Vec3,
+	rotation model.Quat,
+) (bool, int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.InsertBuilding(spaceShipId, typeId, position, size, rotation)
+}
+
+func InsertStarterBuilding(spaceShipId int64, typeModel string, position model.Vec3, size model.Vec3, seed string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.InsertStarterBuilding(spaceShipId, typeModel, position, size, seed)
+}
+
+func DeleteBuilding(spaceShipId int64, buildingId int64) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.DeleteBuilding(spaceShipId, buildingId)
+}
+
+func SetBuildingBuilt(spaceShipId int64, buildingId int64) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.SetBuildingBuilt(spaceShipId, buildingId)
+}
+
+func SetBuildingEnabled(spaceShipId int64, buildingId int64, isEnabled bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.SetBuildingEnabled(spaceShipId, buildingId, isEnabled)
+}
+
+func SetBuildingsState(spaceShipId int64, buildingId int64, model string, state float64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.SetBuildingsState(spaceShipId, buildingId, model, state)
+}
+
+func SetPropellersPowerRate(spaceShipId int64, buildingId int64, powerRate float64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.SetPropellersPowerRate(spaceShipId, buildingId, powerRate)
+}
+
+func GetItems(spaceShipId int64) []model.Item {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetItems(spaceShipId)
+}
+
+func InsertItem(buildingId int64, typeId int64, slotGroupId int64, state float64) int64 {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.InsertItem(buildingId, typeId, slotGroupId, state)
+}
+
+func MoveItem(spaceShipId int64, itemId int64, targetBuildingId int64, targetSlotGroupId int64) bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.MoveItem(spaceShipId, itemId, targetBuildingId, targetSlotGroupId)
+}
+
+func DeleteItems(spaceShipId int64, buildingId int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.DeleteItems(spaceShipId, buildingId)
+}
+
+func PutDataIntoItemVariation(now float64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.PutDataIntoItemVariation(now)
+}
+
+func PutSpaceShipDataIntoItemVariation(spaceShipId int64, passedTimeInSeconds float64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.PutSpaceShipDataIntoItemVariation(spaceShipId, passedTimeInSeconds)
+}
+
+func GetNextItemVariationEnd(spaceShipId int64) (seconds float64, found bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetNextItemVariationEnd(spaceShipId)
+}
+
+func UpdateItemVariationFromTemp() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.UpdateItemVariationFromTemp()
+}
+
+func GetNewStatesFromItemVariation(spaceShipId int64, rowHandler func(
+	itemId int64,
+	newItemState float64,
+	buildingId int64,
+)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.GetNewStatesFromItemVariation(spaceShipId, rowHandler)
+}
+
+func TruncateItemVariation() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.TruncateItemVariation()
+}
+
+func InsertIntoEmptiedBuildingsFromItemVariation() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.InsertIntoEmptiedBuildingsFromItemVariation()
+}
+
+func UpdateEmptiedBuildingsFromTemp() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.UpdateEmptiedBuildingsFromTemp()
+}
+
+func GetDisabledBuildingsFromEmptiedBuildings(spaceShipId int64, rowHandler func(buildingId int64)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.GetDisabledBuildingsFromEmptiedBuildings(spaceShipId, rowHandler)
+}
+
+func TruncateEmptiedBuildings() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.TruncateEmptiedBuildings()
+}
+
+func GetSpaceShipSimulatedAt(spaceShipId int64) float64 {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetSpaceShipSimulatedAt(spaceShipId)
+}
+
+func SetSpaceShipSimulatedAt(spaceShipId int64, simulatedAt float64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.SetSpaceShipSimulatedAt(spaceShipId, simulatedAt)
+}
+
+func SetOnlineSpaceShipsSimulatedAt(simulatedAt float64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.SetOnlineSpaceShipsSimulatedAt(simulatedAt)
+}
+
+func GetVisibleBodies(position model.Vec3) []model.Body {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetVisibleBodies(position)
+}
+
+func InsertBody(typeId int, parentId int64, position model.Vec3, radius float64, seed float64) int64 {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.InsertBody(typeId, parentId, position, radius, seed)
+}
+
+func GetGeneratedChunks(min, max [3]int64) [][3]int64 {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetGeneratedChunks(min, max)
+}
+
+func InsertChunk(position [3]int64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.InsertChunk(position)
+}
+
+func GetBuildingTypes() []model.BuildingType {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetBuildingTypes()
+}
+
+func GetItemGroups(rowHandler func(id int64, name string)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.GetItemGroups(rowHandler)
+}
+
+func GetItemTypes(rowHandler func(id int64, name string, maxState float64)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.GetItemTypes(rowHandler)
+}
+
+func GetItemTypesInItemGroups(rowHandler func(typeId, groupId int64)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.GetItemTypesInItemGroups(rowHandler)
+}
+
+func GetItemSlots() []model.ItemSlot {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current.GetItemSlots()
+}
+
+func GetBodyTypes(rowHandler func(id int64, name string, model string, maxVisibilityDistance float64)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.GetBodyTypes(rowHandler)
+}
+
+// The other goroutines wait for the end of the transaction before using the store. Everything done
+// in the transaction must use the store given to the callback : the package functions would wait too.
+func DeferredTransaction(f func(tx Store) bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	
+	tx := current
+	tx.DeferredTransaction(func() bool {
+		return f(tx)
+	})
+}
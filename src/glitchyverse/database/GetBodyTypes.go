@@ -0,0 +1,64 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+	"github.com/gwenn/gosqlite"
+)
+
+func (store *SqliteStore) GetBodyTypes(rowHandler func(id int64, name string, model string, maxVisibilityDistance float64)) {
+	s, err := store.conn.Prepare(`
+		SELECT
+			body_type_id,
+			body_type_name,
+			body_type_model,
+			body_type_max_visibility_distance
+		FROM body_type
+		;
+	`)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	err = s.Select(func(s *sqlite.Stmt) error {
+		id,          _, err := s.ScanInt64 (0); if err != nil { return err }
+		name,        _      := s.ScanText  (1)
+		model,       _      := s.ScanText  (2)
+		maxViewDist, _, err := s.ScanDouble(3); if err != nil { return err }
+		
+		rowHandler(
+			id,
+			name,
+			model,
+			maxViewDist,
+		)
+		
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+}
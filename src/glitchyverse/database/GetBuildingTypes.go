@@ -27,25 +27,12 @@ package db
 import (
 	"log"
 	"github.com/gwenn/gosqlite"
+	"glitchyverse/model"
 )
 
-func GetBuildingTypes(rowHandler func(
-	id int64,
-	name string,
-	category *string,
-	model string,
-	isGap bool,
-	defaultState float64,
-	isSizeable bool,
-	isContainer bool,
-	isInside *bool,
-	isPositionByRoomUnit bool,
-	minState float64,
-	maxState float64,
-	canExertThrust bool,
-	isControllable bool,
-)) {
-	s, err := db.Prepare(`
+// Returns the building types without their slots (see GetItemSlots)
+func (store *SqliteStore) GetBuildingTypes() []model.BuildingType {
+	s, err := store.conn.Prepare(`
 		SELECT
 			building_type_id,
 			building_type_name,
@@ -69,42 +56,32 @@ func GetBuildingTypes(rowHandler func(
 		log.Panic(err)
 	}
 	
+	types := make([]model.BuildingType, 0)
 	err = s.Select(func(s *sqlite.Stmt) error {
-		id,                   _, err := s.ScanInt64 (0 ); if err != nil { return err }
-		name,                 _      := s.ScanText  (1 )
-		category                     := getNullString(s, 2)
-		model,                _      := s.ScanText  (3 )
-		isGap,                _, err := s.ScanBool  (4 ); if err != nil { return err }
-		defaultState,         _, err := s.ScanDouble(5 ); if err != nil { return err }
-		isSizeable,           _, err := s.ScanBool  (6 ); if err != nil { return err }
-		isContainer,          _, err := s.ScanBool  (7 ); if err != nil { return err }
-		isInside,               err := getNullBool(s, 8); if err != nil { return err }
-		isPositionByRoomUnit, _, err := s.ScanBool  (9 ); if err != nil { return err }
-		minState,             _, err := s.ScanDouble(10); if err != nil { return err }
-		maxState,             _, err := s.ScanDouble(11); if err != nil { return err }
-		canExertThrust,       _, err := s.ScanBool  (12); if err != nil { return err }
-		isControllable,       _, err := s.ScanBool  (13); if err != nil { return err }
+		var t model.BuildingType
+		var err error
 		
-		rowHandler(
-			id,
-			name,
-			category,
-			model,
-			isGap,
-			defaultState,
-			isSizeable,
-			isContainer,
-			isInside,
-			isPositionByRoomUnit,
-			minState,
-			maxState,
-			canExertThrust,
-			isControllable,
-		)
+		t.Id,                   _, err = s.ScanInt64 (0 ); if err != nil { return err }
+		t.Name,                 _      = s.ScanText  (1 )
+		t.Category                     = getNullString(s, 2)
+		t.Model,                _      = s.ScanText  (3 )
+		t.IsGap,                _, err = s.ScanBool  (4 ); if err != nil { return err }
+		t.DefaultState,         _, err = s.ScanDouble(5 ); if err != nil { return err }
+		t.IsSizeable,           _, err = s.ScanBool  (6 ); if err != nil { return err }
+		t.IsContainer,          _, err = s.ScanBool  (7 ); if err != nil { return err }
+		t.IsInside,                err = getNullBool(s, 8); if err != nil { return err }
+		t.IsPositionByRoomUnit, _, err = s.ScanBool  (9 ); if err != nil { return err }
+		t.MinState,             _, err = s.ScanDouble(10); if err != nil { return err }
+		t.MaxState,             _, err = s.ScanDouble(11); if err != nil { return err }
+		t.CanExertThrust,       _, err = s.ScanBool  (12); if err != nil { return err }
+		t.IsControllable,       _, err = s.ScanBool  (13); if err != nil { return err }
 		
+		types = append(types, t)
 		return nil
 	})
 	if err != nil {
 		log.Panic(err)
 	}
+	
+	return types
 }
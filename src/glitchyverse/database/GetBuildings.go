@@ -27,20 +27,12 @@ package db
 import (
 	"log"
 	"github.com/gwenn/gosqlite"
+	"glitchyverse/model"
 )
 
-func GetBuildings(spaceShipId int64, buildingId int64, rowHandler func(
-	id int64,
-	typeId int64,
-	position [3]float64,
-	rotation [4]float64,
-	size [3]float64,
-	state float64,
-	isBuilt bool,
-	seed *string,
-	isEnabled bool,
-)) {
-	s, err := db.Prepare(`
+// Returns the buildings without their items. All of them if buildingId <= 0.
+func (store *SqliteStore) GetBuildings(spaceShipId int64, buildingId int64) []model.Building {
+	s, err := store.conn.Prepare(`
 		SELECT
 			building_id,
 			building_type_id,
@@ -68,44 +60,34 @@ func GetBuildings(spaceShipId int64, buildingId int64, rowHandler func(
 		log.Panic(err)
 	}
 	
+	buildings := make([]model.Building, 0)
 	err = s.Select(func(s *sqlite.Stmt) error {
-		var position [3]float64
-		var rotation [4]float64
-		var size [3]float64
+		var b model.Building
 		var err error
 		
-		id,          _, err := s.ScanInt64 (0 ); if err != nil { return err }
-		typeId,      _, err := s.ScanInt64 (1 ); if err != nil { return err }
-		position[0], _, err  = s.ScanDouble(2 ); if err != nil { return err }
-		position[1], _, err  = s.ScanDouble(3 ); if err != nil { return err }
-		position[2], _, err  = s.ScanDouble(4 ); if err != nil { return err }
-		rotation[0], _, err  = s.ScanDouble(5 ); if err != nil { return err }
-		rotation[1], _, err  = s.ScanDouble(6 ); if err != nil { return err }
-		rotation[2], _, err  = s.ScanDouble(7 ); if err != nil { return err }
-		rotation[3], _, err  = s.ScanDouble(8 ); if err != nil { return err }
-		size[0],     _, err  = s.ScanDouble(9 ); if err != nil { return err }
-		size[1],     _, err  = s.ScanDouble(10); if err != nil { return err }
-		size[2],     _, err  = s.ScanDouble(11); if err != nil { return err }
-		state,       _, err := s.ScanDouble(12); if err != nil { return err }
-		isBuilt,     _, err := s.ScanBool  (13); if err != nil { return err }
-		seed                := getNullString(s, 14)
-		isEnabled,   _, err := s.ScanBool  (15); if err != nil { return err }
-		
-		rowHandler(
-			id,
-			typeId,
-			position,
-			rotation,
-			size,
-			state,
-			isBuilt,
-			seed,
-			isEnabled,
-		)
+		b.Id,          _, err = s.ScanInt64 (0 ); if err != nil { return err }
+		b.TypeId,      _, err = s.ScanInt64 (1 ); if err != nil { return err }
+		b.Position[0], _, err = s.ScanDouble(2 ); if err != nil { return err }
+		b.Position[1], _, err = s.ScanDouble(3 ); if err != nil { return err }
+		b.Position[2], _, err = s.ScanDouble(4 ); if err != nil { return err }
+		b.Rotation[0], _, err = s.ScanDouble(5 ); if err != nil { return err }
+		b.Rotation[1], _, err = s.ScanDouble(6 ); if err != nil { return err }
+		b.Rotation[2], _, err = s.ScanDouble(7 ); if err != nil { return err }
+		b.Rotation[3], _, err = s.ScanDouble(8 ); if err != nil { return err }
+		b.Size[0],     _, err = s.ScanDouble(9 ); if err != nil { return err }
+		b.Size[1],     _, err = s.ScanDouble(10); if err != nil { return err }
+		b.Size[2],     _, err = s.ScanDouble(11); if err != nil { return err }
+		b.State,       _, err = s.ScanDouble(12); if err != nil { return err }
+		b.IsBuilt,     _, err = s.ScanBool  (13); if err != nil { return err }
+		b.Seed                = getNullString(s, 14)
+		b.IsEnabled,   _, err = s.ScanBool  (15); if err != nil { return err }
 		
+		buildings = append(buildings, b)
 		return nil
 	}, spaceShipId, buildingId)
 	if err != nil {
 		log.Panic(err)
 	}
+	
+	return buildings
 }
@@ -29,8 +29,8 @@ import (
 	"github.com/gwenn/gosqlite"
 )
 
-func GetFirstSpaceShipId(userId int64) (spaceShipId int64) {
-	s, err := db.Prepare(`
+func (store *SqliteStore) GetFirstSpaceShipId(userId int64) (spaceShipId int64) {
+	s, err := store.conn.Prepare(`
 		SELECT MIN(spaceship_id) AS spaceship_id
 		FROM spaceship
 		WHERE user_id = ?1
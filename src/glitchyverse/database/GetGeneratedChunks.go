@@ -30,8 +30,8 @@ import (
 )
 
 // Returns the position of the chunks which has already been generated in the given coordinates range
-func GetGeneratedChunks(min, max [3]int64) [][3]int64 {
-	s, err := db.Prepare(`
+func (store *SqliteStore) GetGeneratedChunks(min, max [3]int64) [][3]int64 {
+	s, err := store.conn.Prepare(`
 		SELECT
 			chunk_position_x,
 			chunk_position_y,
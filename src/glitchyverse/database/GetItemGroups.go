@@ -29,8 +29,8 @@ import (
 	"github.com/gwenn/gosqlite"
 )
 
-func GetItemGroups(rowHandler func(id int64, name string)) {
-	s, err := db.Prepare(`
+func (store *SqliteStore) GetItemGroups(rowHandler func(id int64, name string)) {
+	s, err := store.conn.Prepare(`
 		SELECT
 			item_group_id,
 			item_group_name
@@ -27,10 +27,11 @@ package db
 import (
 	"log"
 	"github.com/gwenn/gosqlite"
+	"glitchyverse/model"
 )
 
-func GetItemSlots(rowHandler func(buildingTypeId, itemGroupId int64, whenBuilding bool, maxAmount int64, variation float64)) {
-	s, err := db.Prepare(`
+func (store *SqliteStore) GetItemSlots() []model.ItemSlot {
+	s, err := store.conn.Prepare(`
 		SELECT
 			building_type_id,
 			item_group_id,
@@ -47,24 +48,23 @@ func GetItemSlots(rowHandler func(buildingTypeId, itemGroupId int64, whenBuildin
 		log.Panic(err)
 	}
 	
+	slots := make([]model.ItemSlot, 0)
 	err = s.Select(func(s *sqlite.Stmt) error {
-		buildingTypeId,  _, err := s.ScanInt64 (0); if err != nil { return err }
-		itemGroupId,     _, err := s.ScanInt64 (1); if err != nil { return err }
-		whenBuilding,    _, err := s.ScanBool  (2); if err != nil { return err }
-		maxAmount,       _, err := s.ScanInt64 (3); if err != nil { return err }
-		variation,       _, err := s.ScanDouble(4); if err != nil { return err }
+		var slot model.ItemSlot
+		var err error
 		
-		rowHandler(
-			buildingTypeId,
-			itemGroupId,
-			whenBuilding,
-			maxAmount,
-			variation,
-		)
+		slot.BuildingTypeId, _, err = s.ScanInt64 (0); if err != nil { return err }
+		slot.Group,          _, err = s.ScanInt64 (1); if err != nil { return err }
+		slot.WhenBuilding,   _, err = s.ScanBool  (2); if err != nil { return err }
+		slot.MaximumAmount,  _, err = s.ScanInt64 (3); if err != nil { return err }
+		slot.StateVariation, _, err = s.ScanDouble(4); if err != nil { return err }
 		
+		slots = append(slots, slot)
 		return nil
 	})
 	if err != nil {
 		log.Panic(err)
 	}
+	
+	return slots
 }
@@ -29,8 +29,8 @@ import (
 	"github.com/gwenn/gosqlite"
 )
 
-func GetItemTypes(rowHandler func(id int64, name string, maxState float64)) {
-	s, err := db.Prepare(`
+func (store *SqliteStore) GetItemTypes(rowHandler func(id int64, name string, maxState float64)) {
+	s, err := store.conn.Prepare(`
 		SELECT
 			item_type_id,
 			item_type_name,
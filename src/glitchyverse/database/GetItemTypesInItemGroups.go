@@ -29,8 +29,8 @@ import (
 	"github.com/gwenn/gosqlite"
 )
 
-func GetItemTypesInItemGroups(rowHandler func(typeId, groupId int64)) {
-	s, err := db.Prepare(`
+func (store *SqliteStore) GetItemTypesInItemGroups(rowHandler func(typeId, groupId int64)) {
+	s, err := store.conn.Prepare(`
 		SELECT
 			item_type_id,
 			item_group_id
@@ -27,10 +27,11 @@ package db
 import (
 	"log"
 	"github.com/gwenn/gosqlite"
+	"glitchyverse/model"
 )
 
-func GetItems(spaceShipId int64, rowHandler func(id, typeId int64, state float64, buildingId int64, slotGroupId *int64)) {
-	s, err := db.Prepare(`
+func (store *SqliteStore) GetItems(spaceShipId int64) []model.Item {
+	s, err := store.conn.Prepare(`
 		SELECT
 			item_id,
 			item_type_id,
@@ -46,24 +47,23 @@ func GetItems(spaceShipId int64, rowHandler func(id, typeId int64, state float64
 		log.Panic(err)
 	}
 	
+	items := make([]model.Item, 0)
 	err = s.Select(func(s *sqlite.Stmt) error {
-		id,          _, err := s.ScanInt64 (0); if err != nil { return err }
-		typeId,      _, err := s.ScanInt64 (1); if err != nil { return err }
-		state,       _, err := s.ScanDouble(2); if err != nil { return err }
-		buildingId,  _, err := s.ScanInt64 (3); if err != nil { return err }
-		slotGroupId, err := getNullInt64(s, 4); if err != nil { return err }
+		var item model.Item
+		var err error
 		
-		rowHandler(
-			id,
-			typeId,
-			state,
-			buildingId,
-			slotGroupId,
-		)
+		item.Id,          _, err = s.ScanInt64 (0); if err != nil { return err }
+		item.TypeId,      _, err = s.ScanInt64 (1); if err != nil { return err }
+		item.State,       _, err = s.ScanDouble(2); if err != nil { return err }
+		item.BuildingId,  _, err = s.ScanInt64 (3); if err != nil { return err }
+		item.SlotGroupId, err = getNullInt64(s, 4); if err != nil { return err }
 		
+		items = append(items, item)
 		return nil
 	}, spaceShipId)
 	if err != nil {
 		log.Panic(err)
 	}
+	
+	return items
 }
@@ -27,10 +27,12 @@ package db
 import (
 	"log"
 	"github.com/gwenn/gosqlite"
+	"glitchyverse/model"
 )
 
-func GetSpaceShip(spaceShipId int64) (name string, position [3]float64, rotation [3]float64, found bool) {
-	s, err := db.Prepare(`
+// Returns the spaceship without its buildings
+func (store *SqliteStore) GetSpaceShip(spaceShipId int64) (spaceShip model.Spaceship, found bool) {
+	s, err := store.conn.Prepare(`
 		SELECT
 			spaceship_name,
 			spaceship_position_x,
@@ -38,7 +40,14 @@ func GetSpaceShip(spaceShipId int64) (name string, position [3]float64, rotation
 			spaceship_position_z,
 			spaceship_rotation_x,
 			spaceship_rotation_y,
-			spaceship_rotation_z
+			spaceship_rotation_z,
+			spaceship_rotation_w,
+			spaceship_velocity_x,
+			spaceship_velocity_y,
+			spaceship_velocity_z,
+			spaceship_angular_velocity_x,
+			spaceship_angular_velocity_y,
+			spaceship_angular_velocity_z
 		FROM spaceship
 		WHERE spaceship_id = ?1
 		;
@@ -47,15 +56,24 @@ func GetSpaceShip(spaceShipId int64) (name string, position [3]float64, rotation
 		log.Panic(err)
 	}
 	
+	spaceShip.Id = spaceShipId
 	err = s.Select(func(s *sqlite.Stmt) error {
-		name,        _      = s.ScanText  (0)
-		position[0], _, err = s.ScanDouble(1); if err != nil { return err }
-		position[1], _, err = s.ScanDouble(2); if err != nil { return err }
-		position[2], _, err = s.ScanDouble(3); if err != nil { return err }
-		rotation[0], _, err = s.ScanDouble(4); if err != nil { return err }
-		rotation[1], _, err = s.ScanDouble(5); if err != nil { return err }
-		position[2], _, err = s.ScanDouble(6); if err != nil { return err }
+		spaceShip.Name,               _      = s.ScanText  (0 )
+		spaceShip.Position[0],        _, err = s.ScanDouble(1 ); if err != nil { return err }
+		spaceShip.Position[1],        _, err = s.ScanDouble(2 ); if err != nil { return err }
+		spaceShip.Position[2],        _, err = s.ScanDouble(3 ); if err != nil { return err }
+		spaceShip.Rotation[0],        _, err = s.ScanDouble(4 ); if err != nil { return err }
+		spaceShip.Rotation[1],        _, err = s.ScanDouble(5 ); if err != nil { return err }
+		spaceShip.Rotation[2],        _, err = s.ScanDouble(6 ); if err != nil { return err }
+		spaceShip.Rotation[3],        _, err = s.ScanDouble(7 ); if err != nil { return err }
+		spaceShip.Velocity[0],        _, err = s.ScanDouble(8 ); if err != nil { return err }
+		spaceShip.Velocity[1],        _, err = s.ScanDouble(9 ); if err != nil { return err }
+		spaceShip.Velocity[2],        _, err = s.ScanDouble(10); if err != nil { return err }
+		spaceShip.AngularVelocity[0], _, err = s.ScanDouble(11); if err != nil { return err }
+		spaceShip.AngularVelocity[1], _, err = s.ScanDouble(12); if err != nil { return err }
+		spaceShip.AngularVelocity[2], _, err = s.ScanDouble(13); if err != nil { return err }
 		
+		found = true
 		return nil
 	}, spaceShipId)
 	if err != nil {
@@ -29,13 +29,14 @@ import (
 	"github.com/gwenn/gosqlite"
 )
 
-func GetUserId(name, password string) (userId int64) {
-	s, err := db.Prepare(`
+// Returns the id and the password hash of a user (userId = 0 if the user doesn't exist)
+func (store *SqliteStore) GetUserCredentials(name string) (userId int64, passwordHash string) {
+	s, err := store.conn.Prepare(`
 		SELECT 
-			user_id
+			user_id,
+			user_password
 		FROM user
 		WHERE user_name = ?1
-		AND user_password = ?2
 	`)
 	if err != nil {
 		log.Panic(err)
@@ -46,9 +47,10 @@ func GetUserId(name, password string) (userId int64) {
 		if err != nil {
 			return err
 		}
+		passwordHash, _ = s.ScanText(1)
 		
 		return nil
-	}, name, password)
+	}, name)
 	if err != nil {
 		log.Panic(err)
 	}
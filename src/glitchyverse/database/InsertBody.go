@@ -26,12 +26,13 @@ package db
 
 import (
 	"log"
+	"glitchyverse/model"
 )
 
 // Returns the id of the inserted body
 // parentId <= 0 --> NULL
-func InsertBody(typeId int, parentId int64, position [3]float64, radius float64, seed float64) int64 {
-	s, err := db.Prepare(`
+func (store *SqliteStore) InsertBody(typeId int, parentId int64, position model.Vec3, radius float64, seed float64) int64 {
+	s, err := store.conn.Prepare(`
 		INSERT INTO body (
 			body_id,
 			body_type_id,
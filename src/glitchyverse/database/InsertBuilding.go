@@ -26,17 +26,18 @@ package db
 
 import (
 	"log"
+	"glitchyverse/model"
 )
 
 // Inserts a new building in the database, and checks all constraints.
-func InsertBuilding(
+func (store *SqliteStore) InsertBuilding(
 	spaceShipId int64,
 	typeId int64,
-	position [3]float64,
-	size [3]float64,
-	rotation [4]float64,
+	position model.Vec3,
+	size model.Vec3,
+	rotation model.Quat,
 ) (bool, int64) {
-	id, err := db.Insert(
+	id, err := store.conn.Insert(
 		`
 			INSERT INTO building
 			SELECT DISTINCT
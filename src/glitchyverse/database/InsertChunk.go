@@ -29,8 +29,8 @@ import (
 )
 
 // Inserts a chunk in the list of generated chunk
-func InsertChunk(position [3]int64) {
-	err := db.Exec(`
+func (store *SqliteStore) InsertChunk(position [3]int64) {
+	err := store.conn.Exec(`
 		INSERT INTO chunk (
 			chunk_position_x,
 			chunk_position_y,
@@ -0,0 +1,54 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+)
+
+// Returns the id of the inserted item. No constraint is checked (used to give items to the players).
+// slotGroupId <= 0 --> NULL (not in a slot)
+func (store *SqliteStore) InsertItem(buildingId int64, typeId int64, slotGroupId int64, state float64) int64 {
+	id, err := store.conn.Insert(`
+		INSERT INTO item (
+			item_id,
+			item_type_id,
+			building_id,
+			item_slot_group_id,
+			item_state
+		) VALUES (
+			NULL,
+			?1,
+			?2,
+			?3,
+			?4
+		);
+	`, typeId, buildingId, int64ToNull(slotGroupId), state)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	return id
+}
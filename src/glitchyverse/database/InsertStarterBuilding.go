@@ -0,0 +1,68 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+	"glitchyverse/model"
+)
+
+// Inserts an already built building, without checking the placement constraints
+// (used to create the initial layout of new spaceships, including characters).
+// The type is identified by its model. An empty seed is stored as NULL.
+func (store *SqliteStore) InsertStarterBuilding(spaceShipId int64, typeModel string, position model.Vec3, size model.Vec3, seed string) {
+	var seedValue interface{}
+	if seed != "" {
+		seedValue = seed
+	}
+	
+	err := store.conn.Exec(`
+		INSERT INTO building
+		SELECT
+			NULL AS building_id,
+			?1 AS spaceship_id,
+			building_type_id,
+			?3 AS building_position_x,
+			?4 AS building_position_y,
+			?5 AS building_position_z,
+			0 AS building_rotation_x,
+			0 AS building_rotation_y,
+			0 AS building_rotation_z,
+			1 AS building_rotation_w,
+			?6 AS building_size_x,
+			?7 AS building_size_y,
+			?8 AS building_size_z,
+			building_type_default_state,
+			1 AS building_is_built,
+			?9 AS building_seed,
+			1 AS building_is_enabled
+		FROM building_type
+		WHERE building_type_model = ?2
+		;
+	`, spaceShipId, typeModel, position[0], position[1], position[2], size[0], size[1], size[2], seedValue)
+	if err != nil {
+		log.Panic(err)
+	}
+}
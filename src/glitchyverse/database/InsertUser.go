@@ -0,0 +1,57 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+)
+
+// Returns false if the name is already used
+func (store *SqliteStore) InsertUser(name string, passwordHash string) (bool, int64) {
+	changes, err := store.conn.ExecDml(`
+		INSERT INTO user (
+			user_id,
+			user_name,
+			user_password
+		)
+		SELECT
+			NULL,
+			?1,
+			?2
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM user
+			WHERE user_name = ?1
+		);
+	`, name, passwordHash)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	if changes == 0 {
+		return false, 0
+	}
+	return true, store.conn.LastInsertRowid()
+}
@@ -0,0 +1,110 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+	"github.com/gwenn/gosqlite"
+)
+
+func (store *SqliteStore) InsertLoginAttempt(userName string, ip string, result string, at int64) {
+	err := store.conn.Exec(`
+		INSERT INTO login_attempt (
+			login_attempt_id,
+			login_attempt_user_name,
+			login_attempt_ip,
+			login_attempt_result,
+			login_attempt_at
+		) VALUES (
+			NULL,
+			?1,
+			?2,
+			?3,
+			?4
+		);
+	`, userName, ip, result, at)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+// Returns 0 if the account isn't locked
+func (store *SqliteStore) GetLoginLockout(userName string) (until int64) {
+	s, err := store.conn.Prepare(`
+		SELECT login_lockout_until
+		FROM login_lockout
+		WHERE login_lockout_user_name = ?1
+		;
+	`)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	err = s.Select(func(s *sqlite.Stmt) error {
+		until, _, err = s.ScanInt64(0)
+		return err
+	}, userName)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	return
+}
+
+// Deletes the attempts older than the date, and the lockouts which have ended before it
+func (store *SqliteStore) DeleteLoginAudit(before int64) {
+	err := store.conn.Exec(`
+		DELETE FROM login_attempt
+		WHERE login_attempt_at < ?1
+		;
+	`, before)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	err = store.conn.Exec(`
+		DELETE FROM login_lockout
+		WHERE login_lockout_until < ?1
+		;
+	`, before)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+func (store *SqliteStore) SetLoginLockout(userName string, until int64) {
+	err := store.conn.Exec(`
+		INSERT OR REPLACE INTO login_lockout (
+			login_lockout_user_name,
+			login_lockout_until
+		) VALUES (
+			?1,
+			?2
+		);
+	`, userName, until)
+	if err != nil {
+		log.Panic(err)
+	}
+}
@@ -0,0 +1,266 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"reflect"
+	"sort"
+	"glitchyverse/model"
+)
+
+// Store keeping everything in memory, for tests and ephemeral servers.
+// It starts without any definition (building types, items ...), see content.NewMemoryStore.
+// Like SqliteStore, it isn't safe for concurrent use : the package functions serialize its use.
+type MemoryStore struct {
+	tables *memoryTables
+	
+	// Restores what the running transaction modified, in reverse order
+	inTransaction bool
+	undo          []func()
+	
+	// Definitions, written before using the store (not restored by a rollback)
+	categories        map[int64]string
+	buildingTypes     map[int64]memoryBuildingType
+	itemGroups        map[int64]string
+	itemTypes         map[int64]memoryItemType
+	itemTypesInGroups map[[2]int64]bool // [type id, group id]
+	itemSlots         []memoryItemSlot  // In the order of GetItemSlots
+	bodyTypes         map[int64]memoryBodyType
+}
+
+// Data which can be modified, and restored when a transaction is rolled back
+type memoryTables struct {
+	lastIds          map[string]int64 // Last id given in each table (AUTOINCREMENT)
+	users            map[int64]memoryUser
+	sessions         map[int64]memorySession
+	loginAttempts    []memoryLoginAttempt
+	loginLockouts    map[string]int64
+	incidents        []memoryMovementIncident
+	online           map[int64]int64 // Spaceship id by user id
+	spaceShips       map[int64]memorySpaceShip
+	buildings        map[int64]memoryBuilding
+	items            map[int64]memoryItem
+	bodies           map[int64]memoryBody
+	chunks           map[[3]int64]bool
+	itemVariation    map[int64]float64 // New item states by item id
+	emptiedBuildings map[int64]bool
+}
+
+type memoryUser struct {
+	name         string
+	passwordHash string
+}
+
+type memorySession struct {
+	userId     int64
+	tokenHash  string
+	label      string
+	createdAt  int64
+	lastUsedAt int64
+	expiresAt  int64
+	isRevoked  bool
+}
+
+type memoryLoginAttempt struct {
+	userName string
+	ip       string
+	result   string
+	at       int64
+}
+
+type memoryMovementIncident struct {
+	userId   int64
+	kind     string
+	score    float64
+	response string
+	at       int64
+}
+
+type memorySpaceShip struct {
+	userId          int64
+	name            string
+	position        model.Vec3
+	rotation        model.Quat
+	velocity        model.Vec3
+	angularVelocity model.Vec3
+	simulatedAt     float64
+}
+
+type memoryBuilding struct {
+	spaceShipId int64
+	typeId      int64
+	position    model.Vec3
+	rotation    model.Quat
+	size        model.Vec3
+	state       *float64 // nil for the types without state
+	isBuilt     bool
+	seed        *string
+	isEnabled   bool
+}
+
+type memoryItem struct {
+	typeId      int64
+	state       float64
+	buildingId  int64
+	slotGroupId int64 // 0 = not in a slot
+}
+
+type memoryBody struct {
+	typeId   int64
+	parentId int64 // 0 = no parent
+	position model.Vec3
+	radius   float64
+	seed     float64
+}
+
+type memoryBuildingType struct {
+	name                 string
+	category             *string // nil for the types which can't be built (characters)
+	model                string
+	isGap                bool
+	defaultState         float64
+	isSizeable           bool
+	isContainer          bool
+	isInside             *bool
+	isPositionByRoomUnit bool
+	minState             float64
+	maxState             float64
+	canExertThrust       bool
+	isControllable       bool
+}
+
+// The types without state have no state range
+func (t memoryBuildingType) hasState() bool {
+	return t.minState != t.maxState
+}
+
+type memoryItemType struct {
+	name     string
+	maxState float64
+}
+
+type memoryItemSlot struct {
+	buildingTypeId int64
+	itemGroupId    int64
+	whenBuilding   bool
+	maxAmount      int64
+	variation      float64
+}
+
+type memoryBodyType struct {
+	name                  string
+	model                 string
+	maxVisibilityDistance float64
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tables: &memoryTables{
+			lastIds:          make(map[string]int64),
+			users:            make(map[int64]memoryUser),
+			sessions:         make(map[int64]memorySession),
+			loginLockouts:    make(map[string]int64),
+			online:           make(map[int64]int64),
+			spaceShips:       make(map[int64]memorySpaceShip),
+			buildings:        make(map[int64]memoryBuilding),
+			items:            make(map[int64]memoryItem),
+			bodies:           make(map[int64]memoryBody),
+			chunks:           make(map[[3]int64]bool),
+			itemVariation:    make(map[int64]float64),
+			emptiedBuildings: make(map[int64]bool),
+		},
+		categories:        make(map[int64]string),
+		buildingTypes:     make(map[int64]memoryBuildingType),
+		itemGroups:        make(map[int64]string),
+		itemTypes:         make(map[int64]memoryItemType),
+		itemTypesInGroups: make(map[[2]int64]bool),
+		bodyTypes:         make(map[int64]memoryBodyType),
+	}
+}
+
+func (store *MemoryStore) nextId(table string) int64 {
+	store.keep(store.tables.lastIds, table)
+	store.tables.lastIds[table]++
+	return store.tables.lastIds[table]
+}
+
+// Must be called before modifying or deleting the record of a table (map)
+func (store *MemoryStore) keep(table interface{}, key interface{}) {
+	if !store.inTransaction {
+		return
+	}
+	t, k := reflect.ValueOf(table), reflect.ValueOf(key)
+	previous := t.MapIndex(k) // Not valid if the record doesn't exist, then it's deleted by SetMapIndex
+	store.undo = append(store.undo, func() {
+		t.SetMapIndex(k, previous)
+	})
+}
+
+// Must be called for the modifications not made with keep
+func (store *MemoryStore) onRollback(undo func()) {
+	if store.inTransaction {
+		store.undo = append(store.undo, undo)
+	}
+}
+
+// A rollback only undoes the modifications of the transaction. Transactions can be nested.
+func (store *MemoryStore) DeferredTransaction(f func() bool) {
+	nested := store.inTransaction
+	store.inTransaction = true
+	savepoint := len(store.undo)
+	
+	commit := false
+	defer func() {
+		if !commit {
+			for i := len(store.undo) - 1 ; i >= savepoint ; i-- {
+				store.undo[i]()
+			}
+			store.undo = store.undo[:savepoint]
+		}
+		if !nested {
+			store.inTransaction = false
+			store.undo = nil
+		}
+	}()
+	
+	commit = f()
+}
+
+func (store *MemoryStore) Close() {
+}
+
+// Returns the ids of a table in ascending order, like the rowid order of SQLite
+func sortedIds(count int, loop func(add func(id int64))) []int64 {
+	ids := make([]int64, 0, count)
+	loop(func(id int64) {
+		ids = append(ids, id)
+	})
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
@@ -0,0 +1,131 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"sort"
+)
+
+// Functions writing the definitions of the game content (see the content package).
+// They must be called before the store is used by the other goroutines.
+
+func (store *MemoryStore) UpsertBuildingTypeCategory(id int64, name string) {
+	store.categories[id] = name
+}
+
+func (store *MemoryStore) UpsertItemGroup(id int64, name string) {
+	store.itemGroups[id] = name
+}
+
+// The groups of the item type must be set after with SetItemTypeGroups
+func (store *MemoryStore) UpsertItemType(id int64, name string, maxState float64) {
+	store.itemTypes[id] = memoryItemType{name, maxState}
+}
+
+// Replaces the groups of an item type
+func (store *MemoryStore) SetItemTypeGroups(typeId int64, groupIds []int64) {
+	for key := range store.itemTypesInGroups {
+		if key[0] == typeId {
+			delete(store.itemTypesInGroups, key)
+		}
+	}
+	for _, groupId := range groupIds {
+		store.itemTypesInGroups[[2]int64{typeId, groupId}] = true
+	}
+}
+
+// categoryId <= 0 --> can't be built by the players. The nil states are 0, like NULL for SQLite.
+func (store *MemoryStore) UpsertBuildingType(
+	id int64,
+	categoryId int64,
+	name string,
+	model string,
+	isGap bool,
+	isPositionByRoomUnit bool,
+	defaultState *float64,
+	minState *float64,
+	maxState *float64,
+	isSizeable bool,
+	isContainer bool,
+	isInside *bool,
+	canExertThrust bool,
+	isControllable bool,
+) {
+	var category *string
+	if categoryName, ok := store.categories[categoryId]; ok && categoryId > 0 {
+		category = &categoryName
+	}
+	valueOrZero := func(v *float64) float64 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	}
+	
+	store.buildingTypes[id] = memoryBuildingType{
+		name,
+		category,
+		model,
+		isGap,
+		valueOrZero(defaultState),
+		isSizeable,
+		isContainer,
+		isInside,
+		isPositionByRoomUnit,
+		valueOrZero(minState),
+		valueOrZero(maxState),
+		canExertThrust,
+		isControllable,
+	}
+}
+
+// Removes all the item slots of a building type, before inserting the new ones
+func (store *MemoryStore) DeleteItemSlots(buildingTypeId int64) {
+	slots := store.itemSlots[:0]
+	for _, slot := range store.itemSlots {
+		if slot.buildingTypeId != buildingTypeId {
+			slots = append(slots, slot)
+		}
+	}
+	store.itemSlots = slots
+}
+
+// The groups of the slots must be inserted before
+func (store *MemoryStore) InsertItemSlot(buildingTypeId int64, itemGroupId int64, whenBuilding bool, maxAmount int64, variation float64) {
+	store.itemSlots = append(store.itemSlots, memoryItemSlot{buildingTypeId, itemGroupId, whenBuilding, maxAmount, variation})
+	
+	// Same order as the SQLite store : the "any" slots at last, then by group name
+	sort.SliceStable(store.itemSlots, func(i, j int) bool {
+		a, b := store.itemSlots[i].itemGroupId, store.itemSlots[j].itemGroupId
+		if (a == 0) != (b == 0) {
+			return b == 0
+		}
+		return store.itemGroups[a] < store.itemGroups[b]
+	})
+}
+
+func (store *MemoryStore) UpsertBodyType(id int64, name string, model string, maxVisibilityDistance float64) {
+	store.bodyTypes[id] = memoryBodyType{name, model, maxVisibilityDistance}
+}
@@ -0,0 +1,235 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"math"
+)
+
+// Calls f for the varying items of the spaceship (the first one of each slot group which is not
+// empty if consumed, or full if produced), with their variation per second
+func (store *MemoryStore) forEachVaryingItem(spaceShipId int64, f func(itemId int64, item memoryItem, rate float64)) {
+	t := store.tables
+	for buildingId, building := range t.buildings {
+		if building.spaceShipId != spaceShipId {
+			continue
+		}
+		buildingType := store.buildingTypes[building.typeId]
+		
+		var stateRate float64
+		if !building.isEnabled {
+			stateRate = 0
+		} else if building.state == nil {
+			stateRate = 1
+		} else {
+			stateRate = (*building.state - buildingType.minState) / (buildingType.maxState - buildingType.minState)
+		}
+		
+		for _, slot := range store.itemSlots {
+			if slot.buildingTypeId != building.typeId || slot.whenBuilding {
+				continue
+			}
+			
+			// Only the first item of the slot which can still vary
+			itemId := int64(0)
+			for id, item := range t.items {
+				maxState := store.itemTypes[item.typeId].maxState
+				if item.buildingId == buildingId && item.slotGroupId == slot.itemGroupId &&
+					((slot.variation > 0 && item.state < maxState) || (slot.variation < 0 && item.state > 0)) &&
+					(itemId == 0 || id < itemId) {
+					itemId = id
+				}
+			}
+			if itemId == 0 {
+				continue
+			}
+			
+			f(itemId, t.items[itemId], slot.variation * volume(building.size) * stateRate)
+		}
+	}
+}
+
+func (store *MemoryStore) putItemVariation(spaceShipId int64, passedTimeInSeconds float64) {
+	store.forEachVaryingItem(spaceShipId, func(itemId int64, item memoryItem, rate float64) {
+		newState := item.state + rate * passedTimeInSeconds
+		store.keep(store.tables.itemVariation, itemId)
+		store.tables.itemVariation[itemId] = math.Max(0, math.Min(store.itemTypes[item.typeId].maxState, newState))
+	})
+}
+
+// Same computation as the SQLite store, for the spaceships of the online users
+func (store *MemoryStore) PutDataIntoItemVariation(now float64) {
+	for _, spaceShipId := range store.tables.online {
+		passedTime := math.Max(0, now - store.tables.spaceShips[spaceShipId].simulatedAt)
+		store.putItemVariation(spaceShipId, passedTime)
+	}
+}
+
+func (store *MemoryStore) PutSpaceShipDataIntoItemVariation(spaceShipId int64, passedTimeInSeconds float64) {
+	store.putItemVariation(spaceShipId, passedTimeInSeconds)
+}
+
+func (store *MemoryStore) GetNextItemVariationEnd(spaceShipId int64) (seconds float64, found bool) {
+	store.forEachVaryingItem(spaceShipId, func(itemId int64, item memoryItem, rate float64) {
+		var end float64
+		if rate > 0 {
+			end = (store.itemTypes[item.typeId].maxState - item.state) / rate
+		} else if rate < 0 {
+			end = item.state / -rate
+		} else {
+			return
+		}
+		
+		if !found || end < seconds {
+			seconds, found = end, true
+		}
+	})
+	return
+}
+
+func (store *MemoryStore) GetSpaceShipSimulatedAt(spaceShipId int64) float64 {
+	return store.tables.spaceShips[spaceShipId].simulatedAt
+}
+
+func (store *MemoryStore) SetSpaceShipSimulatedAt(spaceShipId int64, simulatedAt float64) {
+	if spaceShip, ok := store.tables.spaceShips[spaceShipId]; ok {
+		spaceShip.simulatedAt = simulatedAt
+		store.keep(store.tables.spaceShips, spaceShipId)
+		store.tables.spaceShips[spaceShipId] = spaceShip
+	}
+}
+
+func (store *MemoryStore) SetOnlineSpaceShipsSimulatedAt(simulatedAt float64) {
+	for _, spaceShipId := range store.tables.online {
+		if spaceShip, ok := store.tables.spaceShips[spaceShipId]; ok {
+			spaceShip.simulatedAt = simulatedAt
+			store.keep(store.tables.spaceShips, spaceShipId)
+			store.tables.spaceShips[spaceShipId] = spaceShip
+		}
+	}
+}
+
+func (store *MemoryStore) UpdateItemVariationFromTemp() {
+	for itemId, newState := range store.tables.itemVariation {
+		if item, ok := store.tables.items[itemId]; ok {
+			item.state = newState
+			store.keep(store.tables.items, itemId)
+			store.tables.items[itemId] = item
+		}
+	}
+}
+
+func (store *MemoryStore) GetNewStatesFromItemVariation(spaceShipId int64, rowHandler func(
+	itemId int64,
+	newItemState float64,
+	buildingId int64,
+)) {
+	t := store.tables
+	ids := sortedIds(len(t.itemVariation), func(add func(id int64)) {
+		for id := range t.itemVariation {
+			if item, ok := t.items[id]; ok && t.buildings[item.buildingId].spaceShipId == spaceShipId {
+				add(id)
+			}
+		}
+	})
+	newStates := make([]float64, len(ids))
+	buildingIds := make([]int64, len(ids))
+	for i, id := range ids {
+		newStates[i] = t.itemVariation[id]
+		buildingIds[i] = t.items[id].buildingId
+	}
+	
+	for i, id := range ids {
+		rowHandler(id, newStates[i], buildingIds[i])
+	}
+}
+
+func (store *MemoryStore) TruncateItemVariation() {
+	itemVariation := store.tables.itemVariation
+	store.onRollback(func() { store.tables.itemVariation = itemVariation })
+	store.tables.itemVariation = make(map[int64]float64)
+}
+
+// Buildings which consume items, and where there are no items left
+func (store *MemoryStore) InsertIntoEmptiedBuildingsFromItemVariation() {
+	t := store.tables
+	for itemId := range t.itemVariation {
+		buildingId := t.items[itemId].buildingId
+		building, ok := t.buildings[buildingId]
+		if !ok {
+			continue
+		}
+		
+		hasItems := false
+		total := 0.0
+		for _, slot := range store.itemSlots {
+			if slot.buildingTypeId != building.typeId || slot.variation >= 0 || slot.whenBuilding {
+				continue
+			}
+			for _, item := range t.items {
+				if item.buildingId == buildingId && item.slotGroupId == slot.itemGroupId {
+					hasItems = true
+					total += item.state
+				}
+			}
+		}
+		
+		if hasItems && total <= 0 {
+			store.keep(t.emptiedBuildings, buildingId)
+			t.emptiedBuildings[buildingId] = true
+		}
+	}
+}
+
+func (store *MemoryStore) UpdateEmptiedBuildingsFromTemp() {
+	for buildingId := range store.tables.emptiedBuildings {
+		if building, ok := store.tables.buildings[buildingId]; ok {
+			building.isEnabled = false
+			store.keep(store.tables.buildings, buildingId)
+			store.tables.buildings[buildingId] = building
+		}
+	}
+}
+
+func (store *MemoryStore) GetDisabledBuildingsFromEmptiedBuildings(spaceShipId int64, rowHandler func(buildingId int64)) {
+	t := store.tables
+	ids := sortedIds(len(t.emptiedBuildings), func(add func(id int64)) {
+		for id := range t.emptiedBuildings {
+			if building, ok := t.buildings[id]; ok && building.spaceShipId == spaceShipId {
+				add(id)
+			}
+		}
+	})
+	
+	for _, id := range ids {
+		rowHandler(id)
+	}
+}
+
+func (store *MemoryStore) TruncateEmptiedBuildings() {
+	emptiedBuildings := store.tables.emptiedBuildings
+	store.onRollback(func() { store.tables.emptiedBuildings = emptiedBuildings })
+	store.tables.emptiedBuildings = make(map[int64]bool)
+}
@@ -0,0 +1,195 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"sort"
+	"glitchyverse/model"
+)
+
+func (store *MemoryStore) GetVisibleBodies(position model.Vec3) []model.Body {
+	t := store.tables
+	ids := sortedIds(len(t.bodies), func(add func(id int64)) {
+		for id, body := range t.bodies {
+			if distance(body.position, position) <= store.bodyTypes[body.typeId].maxVisibilityDistance {
+				add(id)
+			}
+		}
+	})
+	
+	bodies := make([]model.Body, len(ids))
+	for i, id := range ids {
+		body := t.bodies[id]
+		var parentId *int64
+		if body.parentId > 0 {
+			parentId = &body.parentId
+		}
+		bodies[i] = model.Body{
+			Id      : id,
+			TypeId  : body.typeId,
+			ParentId: parentId,
+			Model   : store.bodyTypes[body.typeId].model,
+			Position: body.position,
+			Radius  : body.radius,
+			Seed    : body.seed,
+		}
+	}
+	return bodies
+}
+
+func (store *MemoryStore) InsertBody(typeId int, parentId int64, position model.Vec3, radius float64, seed float64) int64 {
+	if parentId < 0 {
+		parentId = 0
+	}
+	
+	id := store.nextId("body")
+	store.keep(store.tables.bodies, id)
+	store.tables.bodies[id] = memoryBody{int64(typeId), parentId, position, radius, seed}
+	return id
+}
+
+func (store *MemoryStore) GetGeneratedChunks(min, max [3]int64) [][3]int64 {
+	chunks := make([][3]int64, 0)
+	for chunk := range store.tables.chunks {
+		if chunk[0] >= min[0] && chunk[0] <= max[0] &&
+			chunk[1] >= min[1] && chunk[1] <= max[1] &&
+			chunk[2] >= min[2] && chunk[2] <= max[2] {
+			chunks = append(chunks, chunk)
+		}
+	}
+	
+	// Required by space.go optimized loops
+	sort.Slice(chunks, func(i, j int) bool {
+		for k := 0 ; k < 3 ; k++ {
+			if chunks[i][k] != chunks[j][k] {
+				return chunks[i][k] < chunks[j][k]
+			}
+		}
+		return false
+	})
+	
+	return chunks
+}
+
+func (store *MemoryStore) InsertChunk(position [3]int64) {
+	store.keep(store.tables.chunks, position)
+	store.tables.chunks[position] = true
+}
+
+func (store *MemoryStore) GetBuildingTypes() []model.BuildingType {
+	ids := sortedIds(len(store.buildingTypes), func(add func(id int64)) {
+		for id := range store.buildingTypes {
+			add(id)
+		}
+	})
+	
+	types := make([]model.BuildingType, len(ids))
+	for i, id := range ids {
+		t := store.buildingTypes[id]
+		types[i] = model.BuildingType{
+			Id                  : id,
+			Name                : t.name,
+			Category            : t.category,
+			Model               : t.model,
+			IsGap               : t.isGap,
+			DefaultState        : t.defaultState,
+			IsSizeable          : t.isSizeable,
+			IsContainer         : t.isContainer,
+			IsInside            : t.isInside,
+			IsPositionByRoomUnit: t.isPositionByRoomUnit,
+			MinState            : t.minState,
+			MaxState            : t.maxState,
+			CanExertThrust      : t.canExertThrust,
+			IsControllable      : t.isControllable,
+		}
+	}
+	return types
+}
+
+func (store *MemoryStore) GetItemGroups(rowHandler func(id int64, name string)) {
+	ids := sortedIds(len(store.itemGroups), func(add func(id int64)) {
+		for id := range store.itemGroups {
+			add(id)
+		}
+	})
+	
+	for _, id := range ids {
+		rowHandler(id, store.itemGroups[id])
+	}
+}
+
+func (store *MemoryStore) GetItemTypes(rowHandler func(id int64, name string, maxState float64)) {
+	ids := sortedIds(len(store.itemTypes), func(add func(id int64)) {
+		for id := range store.itemTypes {
+			add(id)
+		}
+	})
+	
+	for _, id := range ids {
+		rowHandler(id, store.itemTypes[id].name, store.itemTypes[id].maxState)
+	}
+}
+
+func (store *MemoryStore) GetItemTypesInItemGroups(rowHandler func(typeId, groupId int64)) {
+	// Sorted, because the order changes the hash of the definitions sent to the clients
+	rows := make([][2]int64, 0, len(store.itemTypesInGroups))
+	for typeAndGroup := range store.itemTypesInGroups {
+		rows = append(rows, typeAndGroup)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i][0] < rows[j][0] || (rows[i][0] == rows[j][0] && rows[i][1] < rows[j][1])
+	})
+	
+	for _, row := range rows {
+		rowHandler(row[0], row[1])
+	}
+}
+
+func (store *MemoryStore) GetItemSlots() []model.ItemSlot {
+	slots := make([]model.ItemSlot, len(store.itemSlots))
+	for i, slot := range store.itemSlots {
+		slots[i] = model.ItemSlot{
+			BuildingTypeId: slot.buildingTypeId,
+			Group         : slot.itemGroupId,
+			WhenBuilding  : slot.whenBuilding,
+			MaximumAmount : slot.maxAmount,
+			StateVariation: slot.variation,
+		}
+	}
+	return slots
+}
+
+func (store *MemoryStore) GetBodyTypes(rowHandler func(id int64, name string, model string, maxVisibilityDistance float64)) {
+	ids := sortedIds(len(store.bodyTypes), func(add func(id int64)) {
+		for id := range store.bodyTypes {
+			add(id)
+		}
+	})
+	
+	for _, id := range ids {
+		t := store.bodyTypes[id]
+		rowHandler(id, t.name, t.model, t.maxVisibilityDistance)
+	}
+}
@@ -0,0 +1,441 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"math"
+	"glitchyverse/model"
+)
+
+func (store *MemoryStore) GetFirstSpaceShipId(userId int64) (spaceShipId int64) {
+	for id, spaceShip := range store.tables.spaceShips {
+		if spaceShip.userId == userId && (spaceShipId == 0 || id < spaceShipId) {
+			spaceShipId = id
+		}
+	}
+	return
+}
+
+func (store *MemoryStore) GetSpaceShip(spaceShipId int64) (model.Spaceship, bool) {
+	spaceShip, found := store.tables.spaceShips[spaceShipId]
+	if !found {
+		return model.Spaceship{}, false
+	}
+	return model.Spaceship{
+		Id             : spaceShipId,
+		Name           : spaceShip.name,
+		Position       : spaceShip.position,
+		Rotation       : spaceShip.rotation,
+		Velocity       : spaceShip.velocity,
+		AngularVelocity: spaceShip.angularVelocity,
+	}, true
+}
+
+func (store *MemoryStore) InsertSpaceShip(userId int64, name string) (bool, int64) {
+	for _, spaceShip := range store.tables.spaceShips {
+		if spaceShip.name == name || spaceShip.userId == userId {
+			return false, 0
+		}
+	}
+	
+	id := store.nextId("spaceship")
+	store.keep(store.tables.spaceShips, id)
+	store.tables.spaceShips[id] = memorySpaceShip{userId: userId, name: name, rotation: model.Quat{0, 0, 0, 1}}
+	return true, id
+}
+
+func (store *MemoryStore) SetSpaceShipMotion(spaceShipId int64, position model.Vec3, rotation model.Quat, velocity, angularVelocity model.Vec3) {
+	if spaceShip, ok := store.tables.spaceShips[spaceShipId]; ok {
+		spaceShip.position, spaceShip.rotation = position, rotation
+		spaceShip.velocity, spaceShip.angularVelocity = velocity, angularVelocity
+		store.keep(store.tables.spaceShips, spaceShipId)
+		store.tables.spaceShips[spaceShipId] = spaceShip
+	}
+}
+
+func (store *MemoryStore) GetBuildings(spaceShipId int64, buildingId int64) []model.Building {
+	t := store.tables
+	ids := sortedIds(len(t.buildings), func(add func(id int64)) {
+		for id, building := range t.buildings {
+			if building.spaceShipId == spaceShipId && (buildingId <= 0 || id == buildingId) {
+				add(id)
+			}
+		}
+	})
+	
+	buildings := make([]model.Building, len(ids))
+	for i, id := range ids {
+		b := t.buildings[id]
+		state := 0.0 // Scanning NULL as a float gives 0
+		if b.state != nil {
+			state = *b.state
+		}
+		buildings[i] = model.Building{
+			Id       : id,
+			TypeId   : b.typeId,
+			Position : b.position,
+			Rotation : b.rotation,
+			Size     : b.size,
+			State    : state,
+			IsBuilt  : b.isBuilt,
+			IsEnabled: b.isEnabled,
+			Seed     : b.seed,
+		}
+	}
+	return buildings
+}
+
+// Checks the same constraints as the SQLite store
+func (store *MemoryStore) InsertBuilding(
+	spaceShipId int64,
+	typeId int64,
+	position model.Vec3,
+	size model.Vec3,
+	rotation model.Quat,
+) (bool, int64) {
+	buildingType, ok := store.buildingTypes[typeId]
+	if !ok || buildingType.category == nil {
+		return false, 0
+	}
+	
+	if !buildingType.isSizeable && size != (model.Vec3{1, 1, 1}) {
+		return false, 0
+	}
+	
+	// Checking coordinates values (must be interers or halfs)
+	if buildingType.isGap {
+		// Gap building --> positions must be integer except one of x or z
+		if !isInteger(position[1]) || !(
+			(isInteger(position[0]) && isInteger(position[2] + 0.5)) ||
+			(isInteger(position[0] + 0.5) && isInteger(position[2]))) {
+			return false, 0
+		}
+	} else if !isInteger(position[0]) || !isInteger(position[1]) || !isInteger(position[2]) {
+		return false, 0
+	}
+	
+	// Checking that an inside building is in a container, and an outside building is not
+	if buildingType.isInside != nil {
+		isInContainer := false
+		for _, b := range store.tables.buildings {
+			if b.spaceShipId == spaceShipId && store.buildingTypes[b.typeId].isContainer && overlaps(position, size, b.position, b.size) {
+				isInContainer = true
+				break
+			}
+		}
+		if isInContainer != *buildingType.isInside {
+			return false, 0
+		}
+		
+		// Checking that there is no building on this position with the same value for isInside
+		for _, b := range store.tables.buildings {
+			otherIsInside := store.buildingTypes[b.typeId].isInside
+			if b.spaceShipId == spaceShipId && otherIsInside != nil && *otherIsInside == *buildingType.isInside && overlaps(position, size, b.position, b.size) {
+				return false, 0
+			}
+		}
+	}
+	
+	// If there are no required items to build, it's already built
+	requiredItems := int64(0)
+	for _, slot := range store.itemSlots {
+		if slot.buildingTypeId == typeId && slot.whenBuilding {
+			requiredItems += slot.maxAmount
+		}
+	}
+	
+	id := store.nextId("building")
+	store.keep(store.tables.buildings, id)
+	store.tables.buildings[id] = memoryBuilding{
+		spaceShipId: spaceShipId,
+		typeId:      typeId,
+		position:    position,
+		rotation:    rotation,
+		size:        size,
+		state:       buildingType.newState(),
+		isBuilt:     requiredItems == 0,
+		isEnabled:   true,
+	}
+	return true, id
+}
+
+func (store *MemoryStore) InsertStarterBuilding(spaceShipId int64, typeModel string, position model.Vec3, size model.Vec3, seed string) {
+	var seedValue *string
+	if seed != "" {
+		seedValue = &seed
+	}
+	
+	for typeId, buildingType := range store.buildingTypes {
+		if buildingType.model == typeModel {
+			id := store.nextId("building")
+			store.keep(store.tables.buildings, id)
+			store.tables.buildings[id] = memoryBuilding{
+				spaceShipId: spaceShipId,
+				typeId:      typeId,
+				position:    position,
+				rotation:    model.Quat{0, 0, 0, 1},
+				size:        size,
+				state:       buildingType.newState(),
+				isBuilt:     true,
+				seed:        seedValue,
+				isEnabled:   true,
+			}
+		}
+	}
+}
+
+// Returns the state of a new building, nil for the types without state
+func (t memoryBuildingType) newState() *float64 {
+	if !t.hasState() {
+		return nil
+	}
+	state := t.defaultState
+	return &state
+}
+
+func (store *MemoryStore) DeleteBuilding(spaceShipId int64, buildingId int64) bool {
+	building, ok := store.tables.buildings[buildingId]
+	if !ok || building.spaceShipId != spaceShipId {
+		return false
+	}
+	
+	// Can't destroy un-buildable buildings such as characters
+	buildingType := store.buildingTypes[building.typeId]
+	if buildingType.category == nil {
+		return false
+	}
+	
+	// If the building is a container, checking that there is not a building inside it
+	if buildingType.isContainer {
+		for _, b := range store.tables.buildings {
+			isInside := store.buildingTypes[b.typeId].isInside
+			if b.spaceShipId == spaceShipId && isInside != nil && *isInside && overlaps(building.position, building.size, b.position, b.size) {
+				return false
+			}
+		}
+	}
+	
+	store.deleteBuilding(buildingId)
+	return true
+}
+
+// Deletes a building with its items.
+func (store *MemoryStore) deleteBuilding(buildingId int64) {
+	for itemId, item := range store.tables.items {
+		if item.buildingId == buildingId {
+			store.keep(store.tables.items, itemId)
+			delete(store.tables.items, itemId)
+		}
+	}
+	store.keep(store.tables.buildings, buildingId)
+	delete(store.tables.buildings, buildingId)
+}
+
+func (store *MemoryStore) SetBuildingBuilt(spaceShipId int64, buildingId int64) bool {
+	building, ok := store.tables.buildings[buildingId]
+	if !ok || building.spaceShipId != spaceShipId || building.isBuilt {
+		return false
+	}
+	
+	hasSlots := false
+	requiredItems := int64(0)
+	for _, slot := range store.itemSlots {
+		if slot.buildingTypeId == building.typeId && slot.whenBuilding {
+			hasSlots = true
+			requiredItems += slot.maxAmount
+		}
+	}
+	
+	items := 0
+	for _, item := range store.tables.items {
+		if item.buildingId == buildingId {
+			items++
+		}
+	}
+	
+	if !hasSlots || float64(requiredItems) * volume(building.size) != float64(items) {
+		return false
+	}
+	
+	building.isBuilt = true
+	store.keep(store.tables.buildings, buildingId)
+	store.tables.buildings[buildingId] = building
+	return true
+}
+
+func (store *MemoryStore) SetBuildingEnabled(spaceShipId int64, buildingId int64, isEnabled bool) {
+	if building, ok := store.tables.buildings[buildingId]; ok && building.spaceShipId == spaceShipId {
+		building.isEnabled = isEnabled
+		store.keep(store.tables.buildings, buildingId)
+		store.tables.buildings[buildingId] = building
+	}
+}
+
+func (store *MemoryStore) SetBuildingsState(spaceShipId int64, buildingId int64, typeModel string, state float64) {
+	for id, building := range store.tables.buildings {
+		buildingType := store.buildingTypes[building.typeId]
+		if building.spaceShipId == spaceShipId && (buildingId <= 0 || id == buildingId) &&
+			buildingType.model == typeModel && buildingType.hasState() &&
+			state >= buildingType.minState && state <= buildingType.maxState &&
+			building.isBuilt && building.isEnabled {
+			newState := state
+			building.state = &newState
+			store.keep(store.tables.buildings, id)
+			store.tables.buildings[id] = building
+		}
+	}
+}
+
+func (store *MemoryStore) SetPropellersPowerRate(spaceShipId int64, buildingId int64, powerRate float64) {
+	powerRate = math.Max(-1, math.Min(1, powerRate))
+	
+	for id, building := range store.tables.buildings {
+		buildingType := store.buildingTypes[building.typeId]
+		if building.spaceShipId == spaceShipId && (buildingId <= 0 || id == buildingId) &&
+			buildingType.canExertThrust && building.isBuilt && building.isEnabled {
+			var newState float64
+			if powerRate < 0 {
+				newState = powerRate * -buildingType.minState
+			} else {
+				newState = powerRate * buildingType.maxState
+			}
+			building.state = &newState
+			store.keep(store.tables.buildings, id)
+			store.tables.buildings[id] = building
+		}
+	}
+}
+
+func (store *MemoryStore) GetItems(spaceShipId int64) []model.Item {
+	t := store.tables
+	ids := sortedIds(len(t.items), func(add func(id int64)) {
+		for id, item := range t.items {
+			if t.buildings[item.buildingId].spaceShipId == spaceShipId {
+				add(id)
+			}
+		}
+	})
+	
+	items := make([]model.Item, len(ids))
+	for i, id := range ids {
+		item := t.items[id]
+		var slotGroupId *int64
+		if item.slotGroupId > 0 {
+			groupId := item.slotGroupId
+			slotGroupId = &groupId
+		}
+		items[i] = model.Item{
+			Id         : id,
+			TypeId     : item.typeId,
+			State      : item.state,
+			BuildingId : item.buildingId,
+			SlotGroupId: slotGroupId,
+		}
+	}
+	return items
+}
+
+func (store *MemoryStore) InsertItem(buildingId int64, typeId int64, slotGroupId int64, state float64) int64 {
+	if slotGroupId < 0 {
+		slotGroupId = 0
+	}
+	
+	id := store.nextId("item")
+	store.keep(store.tables.items, id)
+	store.tables.items[id] = memoryItem{typeId, state, buildingId, slotGroupId}
+	return id
+}
+
+func (store *MemoryStore) MoveItem(spaceShipId int64, itemId int64, targetBuildingId int64, targetSlotGroupId int64) bool {
+	t := store.tables
+	item, ok := t.items[itemId]
+	if !ok || t.buildings[item.buildingId].spaceShipId != spaceShipId {
+		return false
+	}
+	target, ok := t.buildings[targetBuildingId]
+	if !ok || target.spaceShipId != spaceShipId {
+		return false
+	}
+	if targetSlotGroupId < 0 {
+		targetSlotGroupId = 0
+	}
+	
+	itemsInSlot := int64(0)
+	if targetSlotGroupId > 0 {
+		for _, i := range t.items {
+			if i.buildingId == targetBuildingId && i.slotGroupId == targetSlotGroupId {
+				itemsInSlot++
+			}
+		}
+	}
+	
+	// Looking for a slot of the target accepting this item type, with some free space
+	for _, slot := range store.itemSlots {
+		if slot.buildingTypeId == target.typeId && slot.whenBuilding == !target.isBuilt &&
+			store.itemTypesInGroups[[2]int64{item.typeId, slot.itemGroupId}] && itemsInSlot < slot.maxAmount {
+			item.buildingId = targetBuildingId
+			item.slotGroupId = targetSlotGroupId
+			store.keep(t.items, itemId)
+			t.items[itemId] = item
+			return true
+		}
+	}
+	return false
+}
+
+func (store *MemoryStore) DeleteItems(spaceShipId int64, buildingId int64) {
+	if store.tables.buildings[buildingId].spaceShipId != spaceShipId {
+		return
+	}
+	for id, item := range store.tables.items {
+		if item.buildingId == buildingId {
+			store.keep(store.tables.items, id)
+			delete(store.tables.items, id)
+		}
+	}
+}
+
+// Like ROUND(x) = x in SQL
+func isInteger(x float64) bool {
+	return math.Floor(x) == x
+}
+
+func volume(size model.Vec3) float64 {
+	return size[0] * size[1] * size[2]
+}
+
+func distance(a, b model.Vec3) float64 {
+	return math.Sqrt(math.Pow(a[0] - b[0], 2) + math.Pow(a[1] - b[1], 2) + math.Pow(a[2] - b[2], 2))
+}
+
+// True if two boxes of room units share at least one unit
+func overlaps(positionA, sizeA, positionB, sizeB model.Vec3) bool {
+	for i := 0 ; i < 3 ; i++ {
+		if positionA[i] > positionB[i] + sizeB[i] - 1 || positionA[i] + sizeA[i] - 1 < positionB[i] {
+			return false
+		}
+	}
+	return true
+}
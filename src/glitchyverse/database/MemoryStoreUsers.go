@@ -0,0 +1,240 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+	"sort"
+)
+
+func (store *MemoryStore) GetUserCredentials(name string) (userId int64, passwordHash string) {
+	for id, user := range store.tables.users {
+		if user.name == name {
+			return id, user.passwordHash
+		}
+	}
+	return
+}
+
+func (store *MemoryStore) InsertUser(name string, passwordHash string) (bool, int64) {
+	for _, user := range store.tables.users {
+		if user.name == name {
+			return false, 0
+		}
+	}
+	
+	id := store.nextId("user")
+	store.keep(store.tables.users, id)
+	store.tables.users[id] = memoryUser{name, passwordHash}
+	return true, id
+}
+
+func (store *MemoryStore) SetUserPassword(userId int64, passwordHash string) {
+	if user, ok := store.tables.users[userId]; ok {
+		user.passwordHash = passwordHash
+		store.keep(store.tables.users, userId)
+		store.tables.users[userId] = user
+	}
+}
+
+func (store *MemoryStore) DeleteUser(userId int64) {
+	t := store.tables
+	for spaceShipId, spaceShip := range t.spaceShips {
+		if spaceShip.userId == userId {
+			for buildingId, building := range t.buildings {
+				if building.spaceShipId == spaceShipId {
+					store.deleteBuilding(buildingId)
+				}
+			}
+			store.keep(t.spaceShips, spaceShipId)
+			delete(t.spaceShips, spaceShipId)
+		}
+	}
+	for sessionId, session := range t.sessions {
+		if session.userId == userId {
+			store.keep(t.sessions, sessionId)
+			delete(t.sessions, sessionId)
+		}
+	}
+	store.keep(t.users, userId)
+	delete(t.users, userId)
+}
+
+func (store *MemoryStore) InsertSession(userId int64, tokenHash string, label string, now int64, expiresAt int64) int64 {
+	for _, session := range store.tables.sessions {
+		if session.tokenHash == tokenHash {
+			log.Panic("Duplicated session token hash")
+		}
+	}
+	
+	id := store.nextId("session")
+	store.keep(store.tables.sessions, id)
+	store.tables.sessions[id] = memorySession{
+		userId:     userId,
+		tokenHash:  tokenHash,
+		label:      label,
+		createdAt:  now,
+		lastUsedAt: now,
+		expiresAt:  expiresAt,
+	}
+	return id
+}
+
+func (store *MemoryStore) UseSession(tokenHash string, now int64) (sessionId int64, userId int64, userName string) {
+	for id, session := range store.tables.sessions {
+		if session.tokenHash == tokenHash && !session.isRevoked && session.expiresAt > now {
+			user, ok := store.tables.users[session.userId]
+			if !ok {
+				return
+			}
+			
+			session.lastUsedAt = now
+			store.keep(store.tables.sessions, id)
+			store.tables.sessions[id] = session
+			return id, session.userId, user.name
+		}
+	}
+	return
+}
+
+func (store *MemoryStore) GetSessions(userId int64, now int64, rowHandler func(
+	id int64,
+	label string,
+	createdAt int64,
+	lastUsedAt int64,
+	expiresAt int64,
+)) {
+	ids := make([]int64, 0)
+	sessions := make(map[int64]memorySession)
+	for id, session := range store.tables.sessions {
+		if session.userId == userId && !session.isRevoked && session.expiresAt > now {
+			ids = append(ids, id)
+			sessions[id] = session
+		}
+	}
+	
+	// Most recently used first
+	sort.Slice(ids, func(i, j int) bool {
+		return sessions[ids[i]].lastUsedAt > sessions[ids[j]].lastUsedAt
+	})
+	
+	for _, id := range ids {
+		session := sessions[id]
+		rowHandler(id, session.label, session.createdAt, session.lastUsedAt, session.expiresAt)
+	}
+}
+
+func (store *MemoryStore) RevokeSession(userId int64, sessionId int64) bool {
+	session, ok := store.tables.sessions[sessionId]
+	if !ok || session.userId != userId || session.isRevoked {
+		return false
+	}
+	
+	session.isRevoked = true
+	store.keep(store.tables.sessions, sessionId)
+	store.tables.sessions[sessionId] = session
+	return true
+}
+
+func (store *MemoryStore) RevokeSessions(userId int64) {
+	for id, session := range store.tables.sessions {
+		if session.userId == userId {
+			session.isRevoked = true
+			store.keep(store.tables.sessions, id)
+			store.tables.sessions[id] = session
+		}
+	}
+}
+
+func (store *MemoryStore) DeleteExpiredSessions(now int64) {
+	for id, session := range store.tables.sessions {
+		if session.expiresAt <= now || session.isRevoked {
+			store.keep(store.tables.sessions, id)
+			delete(store.tables.sessions, id)
+		}
+	}
+}
+
+func (store *MemoryStore) InsertLoginAttempt(userName string, ip string, result string, at int64) {
+	count := len(store.tables.loginAttempts)
+	store.onRollback(func() { store.tables.loginAttempts = store.tables.loginAttempts[:count] })
+	store.tables.loginAttempts = append(store.tables.loginAttempts, memoryLoginAttempt{userName, ip, result, at})
+}
+
+func (store *MemoryStore) GetLoginLockout(userName string) (until int64) {
+	return store.tables.loginLockouts[userName]
+}
+
+func (store *MemoryStore) SetLoginLockout(userName string, until int64) {
+	store.keep(store.tables.loginLockouts, userName)
+	store.tables.loginLockouts[userName] = until
+}
+
+func (store *MemoryStore) DeleteLoginAudit(before int64) {
+	// A new slice, the old one being restored by a rollback
+	attempts := store.tables.loginAttempts
+	store.onRollback(func() { store.tables.loginAttempts = attempts })
+	store.tables.loginAttempts = make([]memoryLoginAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		if attempt.at >= before {
+			store.tables.loginAttempts = append(store.tables.loginAttempts, attempt)
+		}
+	}
+	
+	for userName, until := range store.tables.loginLockouts {
+		if until < before {
+			store.keep(store.tables.loginLockouts, userName)
+			delete(store.tables.loginLockouts, userName)
+		}
+	}
+}
+
+func (store *MemoryStore) InsertMovementIncident(userId int64, kind string, score float64, response string, at int64) {
+	count := len(store.tables.incidents)
+	store.onRollback(func() { store.tables.incidents = store.tables.incidents[:count] })
+	store.tables.incidents = append(store.tables.incidents, memoryMovementIncident{userId, kind, score, response, at})
+}
+
+func (store *MemoryStore) InsertUserOnline(userId int64, spaceShipId int64) {
+	store.keep(store.tables.online, userId)
+	store.tables.online[userId] = spaceShipId
+}
+
+func (store *MemoryStore) DeleteUserOnline(userId int64) {
+	store.keep(store.tables.online, userId)
+	delete(store.tables.online, userId)
+}
+
+func (store *MemoryStore) GetOnlineSpaceShipIds() []int64 {
+	return sortedIds(len(store.tables.online), func(add func(id int64)) {
+		added := make(map[int64]bool)
+		for _, spaceShipId := range store.tables.online {
+			if !added[spaceShipId] {
+				added[spaceShipId] = true
+				add(spaceShipId)
+			}
+		}
+	})
+}
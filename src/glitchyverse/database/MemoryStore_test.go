@@ -0,0 +1,138 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"testing"
+)
+
+func TestMemoryTransactionRollback(t *testing.T) {
+	store := NewMemoryStore()
+	
+	store.DeferredTransaction(func() bool {
+		store.InsertUser("ann", "hash")
+		store.InsertLoginAttempt("ann", "127.0.0.1", "success", 1)
+		store.TruncateItemVariation()
+		return false
+	})
+	if userId, _ := store.GetUserCredentials("ann"); userId != 0 {
+		t.Fatalf("user %d not rolled back", userId)
+	}
+	if len(store.tables.loginAttempts) != 0 {
+		t.Fatalf("login attempts not rolled back : %v", store.tables.loginAttempts)
+	}
+	
+	// The ids given by a rolled back transaction are given again, like with AUTOINCREMENT
+	if _, userId := store.InsertUser("bob", "hash"); userId != 1 {
+		t.Fatalf("got user id %d, want 1", userId)
+	}
+}
+
+func TestMemoryNestedTransactions(t *testing.T) {
+	store := NewMemoryStore()
+	
+	store.DeferredTransaction(func() bool {
+		store.InsertUser("ann", "hash")
+		store.DeferredTransaction(func() bool {
+			store.InsertUser("bob", "hash")
+			return false
+		})
+		store.DeferredTransaction(func() bool {
+			store.InsertUser("cid", "hash")
+			return true
+		})
+		return true
+	})
+	
+	for name, exists := range map[string]bool{"ann": true, "bob": false, "cid": true} {
+		if userId, _ := store.GetUserCredentials(name); (userId != 0) != exists {
+			t.Errorf("user %s : got id %d, want existing %v", name, userId, exists)
+		}
+	}
+}
+
+func TestMemoryTransactionPanic(t *testing.T) {
+	store := NewMemoryStore()
+	
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic not propagated")
+			}
+		}()
+		store.DeferredTransaction(func() bool {
+			store.InsertChunk([3]int64{0, 0, 0})
+			panic("failure")
+		})
+	}()
+	
+	if chunks := store.GetGeneratedChunks([3]int64{0, 0, 0}, [3]int64{0, 0, 0}); len(chunks) != 0 {
+		t.Fatalf("chunk not rolled back : %v", chunks)
+	}
+}
+
+// The writes of the other goroutines wait for the end of the transaction, and are not rolled back with it
+func TestTransactionIsolation(t *testing.T) {
+	store := NewMemoryStore()
+	Use(store)
+	done := make(chan struct{})
+	
+	DeferredTransaction(func(tx Store) bool {
+		tx.InsertUser("ann", "hash")
+		go func() {
+			InsertChunk([3]int64{1, 2, 3})
+			close(done)
+		}()
+		return false
+	})
+	<-done
+	
+	if userId, _ := GetUserCredentials("ann"); userId != 0 {
+		t.Fatalf("user %d not rolled back", userId)
+	}
+	if chunks := GetGeneratedChunks([3]int64{1, 2, 3}, [3]int64{1, 2, 3}); len(chunks) != 1 {
+		t.Fatalf("chunk of the other goroutine lost : %v", chunks)
+	}
+}
+
+func TestMemoryDeleteLoginAudit(t *testing.T) {
+	store := NewMemoryStore()
+	store.InsertLoginAttempt("ann", "10.0.0.1", "failure", 100)
+	store.InsertLoginAttempt("ann", "10.0.0.1", "throttled", 200)
+	store.SetLoginLockout("ann", 150)
+	
+	store.DeferredTransaction(func() bool {
+		store.DeleteLoginAudit(200)
+		return false
+	})
+	if len(store.tables.loginAttempts) != 2 || store.GetLoginLockout("ann") != 150 {
+		t.Fatalf("deletion not rolled back : %v", store.tables.loginAttempts)
+	}
+	
+	store.DeleteLoginAudit(200)
+	if len(store.tables.loginAttempts) != 1 || store.GetLoginLockout("ann") != 0 {
+		t.Fatalf("got %v, lockout %d", store.tables.loginAttempts, store.GetLoginLockout("ann"))
+	}
+}
@@ -0,0 +1,213 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+	"sort"
+	"embed"
+	"strconv"
+	"strings"
+	"github.com/gwenn/gosqlite"
+)
+
+// Files named <version>_<name>.up.sql and <version>_<name>.down.sql
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string // SQL script applying the migration
+	Down    string // SQL script reverting the migration
+}
+
+// Returns the embedded migrations, ordered by version
+func Migrations() []Migration {
+	files, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		parts := strings.SplitN(strings.TrimSuffix(file.Name(), ".sql"), "_", 2)
+		if len(parts) != 2 {
+			log.Panic("Invalid migration file name : " + file.Name())
+		}
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			log.Panic("Invalid migration file name : " + file.Name())
+		}
+		
+		content, err := migrationFiles.ReadFile("migrations/" + file.Name())
+		if err != nil {
+			log.Panic(err)
+		}
+		
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version}
+			byVersion[version] = m
+		}
+		
+		if name := strings.TrimSuffix(parts[1], ".up"); name != parts[1] {
+			m.Name = name
+			m.Up = string(content)
+		} else if name := strings.TrimSuffix(parts[1], ".down"); name != parts[1] {
+			m.Name = name
+			m.Down = string(content)
+		} else {
+			log.Panic("Invalid migration file name : " + file.Name())
+		}
+	}
+	
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			log.Panic("Migration " + strconv.Itoa(m.Version) + " must have an up and a down script")
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	
+	return migrations
+}
+
+func (store *SqliteStore) createTableSchemaVersion() {
+	err := store.conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			schema_version_number INTEGER PRIMARY KEY NOT NULL,
+			schema_version_name TEXT NOT NULL,
+			schema_version_applied_at INTEGER NOT NULL
+		);
+	`)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+// Returns the dates (unix timestamps) when the applied migrations have been applied, by version
+func (store *SqliteStore) GetAppliedMigrations() map[int]int64 {
+	store.createTableSchemaVersion()
+	
+	s, err := store.conn.Prepare(`
+		SELECT
+			schema_version_number,
+			schema_version_applied_at
+		FROM schema_version
+		;
+	`)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	applied := make(map[int]int64)
+	err = s.Select(func(s *sqlite.Stmt) error {
+		version,   _, err := s.ScanInt  (0); if err != nil { return err }
+		appliedAt, _, err := s.ScanInt64(1); if err != nil { return err }
+		
+		applied[version] = appliedAt
+		
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	return applied
+}
+
+// Applies the migrations up to the given version (all of them if version <= 0),
+// each one in its own transaction. Returns the applied migrations.
+func (store *SqliteStore) MigrateUp(version int, now int64) []Migration {
+	applied := store.GetAppliedMigrations()
+	done := make([]Migration, 0)
+	
+	for _, m := range Migrations() {
+		if version > 0 && m.Version > version {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		
+		store.migrate(m.Up, `
+			INSERT INTO schema_version (
+				schema_version_number,
+				schema_version_name,
+				schema_version_applied_at
+			) VALUES (
+				?1,
+				?2,
+				?3
+			);
+		`, m.Version, m.Name, now)
+		done = append(done, m)
+	}
+	
+	return done
+}
+
+// Reverts the migrations above the given version, newest first. Returns the reverted migrations.
+func (store *SqliteStore) MigrateDown(version int) []Migration {
+	applied := store.GetAppliedMigrations()
+	migrations := Migrations()
+	done := make([]Migration, 0)
+	
+	for i := len(migrations) - 1 ; i >= 0 ; i-- {
+		m := migrations[i]
+		if m.Version <= version {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		
+		store.migrate(m.Down, `
+			DELETE FROM schema_version
+			WHERE schema_version_number = ?1
+			;
+		`, m.Version)
+		done = append(done, m)
+	}
+	
+	return done
+}
+
+// Runs a migration script and updates schema_version in the same transaction
+func (store *SqliteStore) migrate(script string, versionQuery string, args ...interface{}) {
+	err := store.conn.Transaction(sqlite.Immediate, func(c *sqlite.Conn) error {
+		if err := c.Exec(script); err != nil {
+			return err
+		}
+		return c.Exec(versionQuery, args...)
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+}
@@ -29,9 +29,9 @@ import (
 )
 
 // Moves an item to another building inventory
-func MoveItem(spaceShipId int64, itemId int64, targetBuildingId int64, targetSlotGroupId int64) bool {
+func (store *SqliteStore) MoveItem(spaceShipId int64, itemId int64, targetBuildingId int64, targetSlotGroupId int64) bool {
 	// TODO change the filter on spaceships to allow exchanges between spaceships ?
-	changes, err := db.ExecDml(`
+	changes, err := store.conn.ExecDml(`
 		UPDATE item SET
 			building_id = ?3,
 			item_slot_group_id = ?4
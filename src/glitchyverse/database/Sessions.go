@@ -0,0 +1,193 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+	"github.com/gwenn/gosqlite"
+)
+
+// Returns the id of the inserted session
+func (store *SqliteStore) InsertSession(userId int64, tokenHash string, label string, now int64, expiresAt int64) int64 {
+	id, err := store.conn.Insert(`
+		INSERT INTO session (
+			session_id,
+			user_id,
+			session_token_hash,
+			session_label,
+			session_created_at,
+			session_last_used_at,
+			session_expires_at
+		) VALUES (
+			NULL,
+			?1,
+			?2,
+			?3,
+			?4,
+			?4,
+			?5
+		);
+	`, userId, tokenHash, label, now, expiresAt)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	return id
+}
+
+// Returns the session matching the token if it's still valid (sessionId = 0 otherwise),
+// and marks it as used.
+func (store *SqliteStore) UseSession(tokenHash string, now int64) (sessionId int64, userId int64, userName string) {
+	s, err := store.conn.Prepare(`
+		SELECT
+			session_id,
+			user_id,
+			user_name
+		FROM session
+		NATURAL INNER JOIN user
+		WHERE session_token_hash = ?1
+		AND session_is_revoked = 0
+		AND session_expires_at > ?2
+		;
+	`)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	err = s.Select(func(s *sqlite.Stmt) error {
+		sessionId, _, err = s.ScanInt64(0); if err != nil { return err }
+		userId,    _, err = s.ScanInt64(1); if err != nil { return err }
+		userName,  _      = s.ScanText (2)
+		
+		return nil
+	}, tokenHash, now)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	if sessionId > 0 {
+		err = store.conn.Exec(`
+			UPDATE session
+			SET session_last_used_at = ?2
+			WHERE session_id = ?1
+			;
+		`, sessionId, now)
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+	
+	return
+}
+
+// Returns the sessions of a user which are still valid
+func (store *SqliteStore) GetSessions(userId int64, now int64, rowHandler func(
+	id int64,
+	label string,
+	createdAt int64,
+	lastUsedAt int64,
+	expiresAt int64,
+)) {
+	s, err := store.conn.Prepare(`
+		SELECT
+			session_id,
+			session_label,
+			session_created_at,
+			session_last_used_at,
+			session_expires_at
+		FROM session
+		WHERE user_id = ?1
+		AND session_is_revoked = 0
+		AND session_expires_at > ?2
+		ORDER BY session_last_used_at DESC
+		;
+	`)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	err = s.Select(func(s *sqlite.Stmt) error {
+		id,         _, err := s.ScanInt64(0); if err != nil { return err }
+		label,      _      := s.ScanText (1)
+		createdAt,  _, err := s.ScanInt64(2); if err != nil { return err }
+		lastUsedAt, _, err := s.ScanInt64(3); if err != nil { return err }
+		expiresAt,  _, err := s.ScanInt64(4); if err != nil { return err }
+		
+		rowHandler(
+			id,
+			label,
+			createdAt,
+			lastUsedAt,
+			expiresAt,
+		)
+		
+		return nil
+	}, userId, now)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+// Returns false if the session doesn't exist or doesn't belong to the user
+func (store *SqliteStore) RevokeSession(userId int64, sessionId int64) bool {
+	changes, err := store.conn.ExecDml(`
+		UPDATE session
+		SET session_is_revoked = 1
+		WHERE session_id = ?2
+		AND user_id = ?1
+		AND session_is_revoked = 0
+		;
+	`, userId, sessionId)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	return (changes > 0)
+}
+
+// Revokes all the sessions of a user, for example after a password change
+func (store *SqliteStore) RevokeSessions(userId int64) {
+	err := store.conn.Exec(`
+		UPDATE session
+		SET session_is_revoked = 1
+		WHERE user_id = ?1
+		;
+	`, userId)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+func (store *SqliteStore) DeleteExpiredSessions(now int64) {
+	err := store.conn.Exec(`
+		DELETE FROM session
+		WHERE session_expires_at <= ?1
+		OR session_is_revoked = 1
+		;
+	`, now)
+	if err != nil {
+		log.Panic(err)
+	}
+}
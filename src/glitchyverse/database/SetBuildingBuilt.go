@@ -29,8 +29,8 @@ import (
 )
 
 // Changes the state of a building from "not built" to "built", only if the requirements (items) are met
-func SetBuildingBuilt(spaceShipId int64, buildingId int64) bool {
-	changes, err := db.ExecDml(`
+func (store *SqliteStore) SetBuildingBuilt(spaceShipId int64, buildingId int64) bool {
+	changes, err := store.conn.ExecDml(`
 		UPDATE building
 		SET building_is_built = 1
 		WHERE spaceship_id = ?1
@@ -28,8 +28,8 @@ import (
 	"log"
 )
 
-func SetBuildingEnabled(spaceShipId int64, buildingId int64, isEnabled bool) {
-	err := db.Exec(`
+func (store *SqliteStore) SetBuildingEnabled(spaceShipId int64, buildingId int64, isEnabled bool) {
+	err := store.conn.Exec(`
 		UPDATE building
 		SET building_is_enabled = ?3
 		WHERE spaceship_id = ?1
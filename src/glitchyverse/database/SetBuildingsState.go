@@ -29,8 +29,8 @@ import (
 )
 
 // Updates the state of one or multiple (buildingId = nil) building(s)
-func SetBuildingsState(spaceShipId int64, buildingId int64, model string, state float64) {
-	err := db.Exec(`
+func (store *SqliteStore) SetBuildingsState(spaceShipId int64, buildingId int64, model string, state float64) {
+	err := store.conn.Exec(`
 		UPDATE building
 		SET building_state = ?4
 		WHERE spaceship_id = ?1
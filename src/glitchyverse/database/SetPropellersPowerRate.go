@@ -28,9 +28,9 @@ import (
 	"log"
 )
 
-// Updates the state of one or multiple (buildingId = nil) propeller(s), based on a rate (-1.0 .. 1.0)
-func SetPropellersPowerRate(spaceShipId int64, buildingId int64, powerRate float64) {
-	err := db.Exec(`
+// Updates the state of one or every (buildingId <= 0) propeller(s), based on a rate (-1.0 .. 1.0)
+func (store *SqliteStore) SetPropellersPowerRate(spaceShipId int64, buildingId int64, powerRate float64) {
+	err := store.conn.Exec(`
 		UPDATE building
 		SET building_state = (
 			CASE
@@ -55,7 +55,7 @@ func SetPropellersPowerRate(spaceShipId int64, buildingId int64, powerRate float
 		)
 		AND building_is_built = 1
 		AND building_is_enabled = 1
-		AND (:building_id IS NULL OR building_id = ?2)
+		AND (?2 <= 0 OR building_id = ?2)
 		;
 	`, spaceShipId, buildingId, powerRate)
 	if err != nil {
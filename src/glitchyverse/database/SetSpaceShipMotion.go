@@ -26,66 +26,46 @@ package db
 
 import (
 	"log"
+	"glitchyverse/model"
 )
 
-// Updates the position and the rotation of the spaceship, only if
-// the new position is coherent with the maximum speed of the ship
-func SetSpaceShipPosition(
-	spaceShipId int64,
-	maxSpeedPerPropellerUnit float64,
-	moveMaximumErrorRate float64,
-	passedTimeRate float64,
-	position [3]float64, // TODO special type vec3 ?
-	rotation [3]float64,
-) bool {
-	changes, err := db.ExecDml(
-		`
-			UPDATE spaceship
-			SET
-				spaceship_position_x = ?5,
-				spaceship_position_y = ?6,
-				spaceship_position_z = ?7,
-				spaceship_rotation_x = ?8,
-				spaceship_rotation_y = ?9,
-				spaceship_rotation_z = ?10
-			WHERE spaceship_id = ?1
-			AND (
-				SQRT(
-					  POW(spaceship_position_x - ?5, 2)
-					+ POW(spaceship_position_y - ?6, 2)
-					+ POW(spaceship_position_z - ?7, 2)
-				) < ?4 * (1 + ?3) * (
-					SELECT
-						SUM(
-							building_size_x
-							* building_size_y
-							* building_size_z
-							* building_type_max_state
-							* ?2
-						) AS max_speed
-					FROM building
-					NATURAL INNER JOIN building_type
-					WHERE spaceship_id = ?1
-					AND building_is_built = 1
-					AND building_is_enabled = 1
-					AND building_type_can_exert_thrust = 1
-				)
-			);
-		`,
+// Saves the position, rotation and velocities computed by the server
+func (store *SqliteStore) SetSpaceShipMotion(spaceShipId int64, position model.Vec3, rotation model.Quat, velocity, angularVelocity model.Vec3) {
+	err := store.conn.Exec(`
+		UPDATE spaceship
+		SET
+			spaceship_position_x = ?2,
+			spaceship_position_y = ?3,
+			spaceship_position_z = ?4,
+			spaceship_rotation_x = ?5,
+			spaceship_rotation_y = ?6,
+			spaceship_rotation_z = ?7,
+			spaceship_rotation_w = ?8,
+			spaceship_velocity_x = ?9,
+			spaceship_velocity_y = ?10,
+			spaceship_velocity_z = ?11,
+			spaceship_angular_velocity_x = ?12,
+			spaceship_angular_velocity_y = ?13,
+			spaceship_angular_velocity_z = ?14
+		WHERE spaceship_id = ?1
+		;
+	`,
 		spaceShipId,
-		maxSpeedPerPropellerUnit,
-		moveMaximumErrorRate,
-		passedTimeRate,
 		position[0],
 		position[1],
 		position[2],
 		rotation[0],
 		rotation[1],
 		rotation[2],
+		rotation[3],
+		velocity[0],
+		velocity[1],
+		velocity[2],
+		angularVelocity[0],
+		angularVelocity[1],
+		angularVelocity[2],
 	)
 	if err != nil {
 		log.Panic(err)
 	}
-	
-	return (changes > 0)
 }
@@ -0,0 +1,82 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"log"
+	"github.com/gwenn/gosqlite"
+)
+
+// Returns the date until which the item production of the spaceship has been applied
+func (store *SqliteStore) GetSpaceShipSimulatedAt(spaceShipId int64) (simulatedAt float64) {
+	s, err := store.conn.Prepare(`
+		SELECT spaceship_last_simulated_at
+		FROM spaceship
+		WHERE spaceship_id = ?1
+		;
+	`)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	err = s.Select(func(s *sqlite.Stmt) error {
+		simulatedAt, _, err = s.ScanDouble(0); if err != nil { return err }
+		
+		return nil
+	}, spaceShipId)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	return
+}
+
+func (store *SqliteStore) SetSpaceShipSimulatedAt(spaceShipId int64, simulatedAt float64) {
+	err := store.conn.Exec(`
+		UPDATE spaceship
+		SET spaceship_last_simulated_at = ?2
+		WHERE spaceship_id = ?1
+		;
+	`, spaceShipId, simulatedAt)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+// Sets the simulation date of the spaceships of the online users
+func (store *SqliteStore) SetOnlineSpaceShipsSimulatedAt(simulatedAt float64) {
+	err := store.conn.Exec(`
+		UPDATE spaceship
+		SET spaceship_last_simulated_at = ?1
+		WHERE spaceship_id IN (
+			SELECT spaceship_id
+			FROM temp_online
+		)
+		;
+	`, simulatedAt)
+	if err != nil {
+		log.Panic(err)
+	}
+}
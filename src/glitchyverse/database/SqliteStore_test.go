@@ -0,0 +1,105 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"github.com/gwenn/gosqlite"
+	"glitchyverse/model"
+)
+
+func openTestSqliteStore(t *testing.T) *SqliteStore {
+	store := OpenSqliteStore(filepath.Join(t.TempDir(), "test.db"))
+	t.Cleanup(store.Close)
+	store.MigrateUp(0, 0)
+	return store
+}
+
+// Every component of the motion must be saved and loaded in its own column
+func TestSqliteSpaceShipMotionRoundTrip(t *testing.T) {
+	store := openTestSqliteStore(t)
+	
+	_, userId := store.InsertUser("ann", "hash")
+	_, spaceShipId := store.InsertSpaceShip(userId, "Ann's ship")
+	
+	position := model.Vec3{1.5, -2.25, 3.75}
+	rotation := model.Quat{0.5, -0.5, 0.5, 0.5}
+	velocity := model.Vec3{4, -5, 6}
+	angularVelocity := model.Vec3{-7, 8, -9}
+	store.SetSpaceShipMotion(spaceShipId, position, rotation, velocity, angularVelocity)
+	
+	spaceShip, found := store.GetSpaceShip(spaceShipId)
+	if !found {
+		t.Fatalf("spaceship %d not found", spaceShipId)
+	}
+	if spaceShip.Name != "Ann's ship" {
+		t.Errorf("name : got %q", spaceShip.Name)
+	}
+	if spaceShip.Position != position {
+		t.Errorf("position : got %v, want %v", spaceShip.Position, position)
+	}
+	if spaceShip.Rotation != rotation {
+		t.Errorf("rotation : got %v, want %v", spaceShip.Rotation, rotation)
+	}
+	if spaceShip.Velocity != velocity {
+		t.Errorf("velocity : got %v, want %v", spaceShip.Velocity, velocity)
+	}
+	if spaceShip.AngularVelocity != angularVelocity {
+		t.Errorf("angular velocity : got %v, want %v", spaceShip.AngularVelocity, angularVelocity)
+	}
+}
+
+func TestSqliteDeleteLoginAudit(t *testing.T) {
+	store := openTestSqliteStore(t)
+	
+	store.InsertLoginAttempt("ann", "10.0.0.1", "failure", 100)
+	store.InsertLoginAttempt("ann", "10.0.0.1", "throttled", 200)
+	store.SetLoginLockout("ann", 150)
+	store.SetLoginLockout("bob", 250)
+	store.DeleteLoginAudit(200)
+	
+	s, err := store.conn.Prepare("SELECT COUNT(*) FROM login_attempt;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attempts int64
+	err = s.Select(func(s *sqlite.Stmt) (err error) {
+		attempts, _, err = s.ScanInt64(0)
+		return
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+	if until := store.GetLoginLockout("ann"); until != 0 {
+		t.Errorf("ended lockout kept : %d", until)
+	}
+	if until := store.GetLoginLockout("bob"); until != 250 {
+		t.Errorf("current lockout : got %d, want 250", until)
+	}
+}
@@ -0,0 +1,145 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package db
+
+import (
+	"sync"
+	"glitchyverse/model"
+)
+
+// Storage of the game data. Like the package functions, the implementations
+// panic when the storage fails, and report refused changes with their results.
+// The package functions use the store given to Use (or Open), and serialize its use :
+// the implementations don't need to be safe for concurrent use.
+type Store interface {
+	// Users and sessions
+	GetUserCredentials(name string) (userId int64, passwordHash string)
+	InsertUser(name string, passwordHash string) (bool, int64)
+	SetUserPassword(userId int64, passwordHash string)
+	DeleteUser(userId int64)
+	InsertSession(userId int64, tokenHash string, label string, now int64, expiresAt int64) int64
+	UseSession(tokenHash string, now int64) (sessionId int64, userId int64, userName string)
+	GetSessions(userId int64, now int64, rowHandler func(
+		id int64,
+		label string,
+		createdAt int64,
+		lastUsedAt int64,
+		expiresAt int64,
+	))
+	RevokeSession(userId int64, sessionId int64) bool
+	RevokeSessions(userId int64)
+	DeleteExpiredSessions(now int64)
+	InsertLoginAttempt(userName string, ip string, result string, at int64)
+	GetLoginLockout(userName string) (until int64)
+	SetLoginLockout(userName string, until int64)
+	DeleteLoginAudit(before int64)
+	InsertMovementIncident(userId int64, kind string, score float64, response string, at int64)
+	
+	// Online users
+	InsertUserOnline(userId int64, spaceShipId int64)
+	DeleteUserOnline(userId int64)
+	GetOnlineSpaceShipIds() []int64
+	
+	// Spaceships
+	GetFirstSpaceShipId(userId int64) (spaceShipId int64)
+	GetSpaceShip(spaceShipId int64) (spaceShip model.Spaceship, found bool) // Without its buildings
+	InsertSpaceShip(userId int64, name string) (bool, int64)
+	SetSpaceShipMotion(spaceShipId int64, position model.Vec3, rotation model.Quat, velocity, angularVelocity model.Vec3) // Without any constraint
+	
+	// Buildings
+	GetBuildings(spaceShipId int64, buildingId int64) []model.Building // Without their items, all of them if buildingId <= 0
+	InsertBuilding(
+		spaceShipId int64,
+		typeId int64,
+		position model.Vec3,
+		size model.Vec3,
+		rotation model.Quat,
+	) (bool, int64)
+	InsertStarterBuilding(spaceShipId int64, typeModel string, position model.Vec3, size model.Vec3, seed string)
+	DeleteBuilding(spaceShipId int64, buildingId int64) bool
+	SetBuildingBuilt(spaceShipId int64, buildingId int64) bool
+	SetBuildingEnabled(spaceShipId int64, buildingId int64, isEnabled bool)
+	SetBuildingsState(spaceShipId int64, buildingId int64, model string, state float64)
+	SetPropellersPowerRate(spaceShipId int64, buildingId int64, powerRate float64)
+	
+	// Items
+	GetItems(spaceShipId int64) []model.Item
+	InsertItem(buildingId int64, typeId int64, slotGroupId int64, state float64) int64 // Without any constraint
+	MoveItem(spaceShipId int64, itemId int64, targetBuildingId int64, targetSlotGroupId int64) bool
+	DeleteItems(spaceShipId int64, buildingId int64)
+	
+	// Item production, in this order : PutDataIntoItemVariation, UpdateItemVariationFromTemp,
+	// InsertIntoEmptiedBuildingsFromItemVariation, UpdateEmptiedBuildingsFromTemp,
+	// then the Get* functions, then TruncateItemVariation and TruncateEmptiedBuildings.
+	// The dates are unix timestamps in seconds, with decimals.
+	PutDataIntoItemVariation(now float64) // Online spaceships, since they have been simulated
+	PutSpaceShipDataIntoItemVariation(spaceShipId int64, passedTimeInSeconds float64)
+	GetNextItemVariationEnd(spaceShipId int64) (seconds float64, found bool)
+	UpdateItemVariationFromTemp()
+	GetNewStatesFromItemVariation(spaceShipId int64, rowHandler func(
+		itemId int64,
+		newItemState float64,
+		buildingId int64,
+	))
+	TruncateItemVariation()
+	InsertIntoEmptiedBuildingsFromItemVariation()
+	UpdateEmptiedBuildingsFromTemp()
+	GetDisabledBuildingsFromEmptiedBuildings(spaceShipId int64, rowHandler func(buildingId int64))
+	TruncateEmptiedBuildings()
+	GetSpaceShipSimulatedAt(spaceShipId int64) float64
+	SetSpaceShipSimulatedAt(spaceShipId int64, simulatedAt float64)
+	SetOnlineSpaceShipsSimulatedAt(simulatedAt float64)
+	
+	// Bodies and chunks
+	GetVisibleBodies(position model.Vec3) []model.Body
+	InsertBody(typeId int, parentId int64, position model.Vec3, radius float64, seed float64) int64
+	GetGeneratedChunks(min, max [3]int64) [][3]int64
+	InsertChunk(position [3]int64)
+	
+	// Definitions
+	GetBuildingTypes() []model.BuildingType // Without their slots
+	GetItemGroups(rowHandler func(id int64, name string))
+	GetItemTypes(rowHandler func(id int64, name string, maxState float64))
+	GetItemTypesInItemGroups(rowHandler func(typeId, groupId int64))
+	GetItemSlots() []model.ItemSlot
+	GetBodyTypes(rowHandler func(id int64, name string, model string, maxVisibilityDistance float64))
+	
+	// Callback must return true to commit, false to rollback.
+	// If the callback panics, the transaction is rolled back before propagating the panic.
+	DeferredTransaction(f func() bool)
+	Close()
+}
+
+var (
+	current Store
+	mutex   sync.Mutex // Serializes the use of current, held by a transaction until its end
+)
+
+// Changes the store used by the package functions
+func Use(store Store) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current = store
+}
@@ -29,8 +29,8 @@ import (
 	"github.com/gwenn/gosqlite"
 )
 
-func createTableEmptiedBuildings() {
-	err := db.Exec(`
+func (store *SqliteStore) createTableEmptiedBuildings() {
+	err := store.conn.Exec(`
 		CREATE TEMPORARY TABLE temp_emptied_buildings (
 			building_id INTEGER
 		);
@@ -41,8 +41,8 @@ func createTableEmptiedBuildings() {
 }
 
 // Inserts into temp_emptied_buildings the buildings which requires items to work, and where there are no items left.
-func InsertIntoEmptiedBuildingsFromItemVariation() {
-	err := db.Exec(`
+func (store *SqliteStore) InsertIntoEmptiedBuildingsFromItemVariation() {
+	err := store.conn.Exec(`
 		INSERT INTO temp_emptied_buildings
 		SELECT
 			building.building_id
@@ -65,8 +65,8 @@ func InsertIntoEmptiedBuildingsFromItemVariation() {
 }
 
 // Returns the buildings which have been disabled
-func GetDisabledBuildingsFromEmptiedBuildings(spaceShipId int64, rowHandler func(buildingId int64)) {
-	s, err := db.Prepare(`
+func (store *SqliteStore) GetDisabledBuildingsFromEmptiedBuildings(spaceShipId int64, rowHandler func(buildingId int64)) {
+	s, err := store.conn.Prepare(`
 		SELECT
 			building_id
 		FROM temp_emptied_buildings
@@ -92,8 +92,8 @@ func GetDisabledBuildingsFromEmptiedBuildings(spaceShipId int64, rowHandler func
 	}
 }
 
-func TruncateEmptiedBuildings() {
-	err := db.Exec(`
+func (store *SqliteStore) TruncateEmptiedBuildings() {
+	err := store.conn.Exec(`
 		DELETE FROM temp_emptied_buildings;
 	`)
 	if err != nil {
@@ -102,8 +102,8 @@ func TruncateEmptiedBuildings() {
 }
 
 // Sets the state of the buildings to disable as disabled
-func UpdateEmptiedBuildingsFromTemp() {
-	err := db.Exec(`
+func (store *SqliteStore) UpdateEmptiedBuildingsFromTemp() {
+	err := store.conn.Exec(`
 		UPDATE building
 		SET building_is_enabled = 0
 		WHERE building_id IN (
@@ -29,8 +29,8 @@ import (
 	"github.com/gwenn/gosqlite"
 )
 
-func createTableItemVariation() {
-	err := db.Exec(`
+func (store *SqliteStore) createTableItemVariation() {
+	err := store.conn.Exec(`
 		CREATE TEMPORARY TABLE temp_item_variation (
 			item_id INTEGER,
 			new_item_state REAL
@@ -42,12 +42,12 @@ func createTableItemVariation() {
 }
 
 // Returns the new states of updated items
-func GetNewStatesFromItemVariation(spaceShipId int64, rowHandler func(
+func (store *SqliteStore) GetNewStatesFromItemVariation(spaceShipId int64, rowHandler func(
 	itemId int64,
 	newItemState float64,
 	buildingId int64,
 )) {
-	s, err := db.Prepare(`
+	s, err := store.conn.Prepare(`
 		SELECT
 			item_id,
 			new_item_state,
@@ -79,66 +79,149 @@ func GetNewStatesFromItemVariation(spaceShipId int64, rowHandler func(
 	}
 }
 
-// Inserts into temp_item_variation the items to update, with their new state, depending on building slots
-// Also uses the temp_online table to know which ones are connected
-func PutDataIntoItemVariation(passedTimeInSeconds float64) {
-	err := db.Exec(`
+// Joins and conditions selecting the items which vary, from a query on building : the first item of each
+// slot group which is not empty (if consumed) or full (if produced)
+const varyingItemsJoins = `
+	INNER JOIN building_type ON building.building_type_id = building_type.building_type_id
+	INNER JOIN item_slot ON building.building_type_id = item_slot.building_type_id
+	INNER JOIN item ON (
+		item.building_id = building.building_id
+		AND item.item_slot_group_id = item_slot.item_group_id
+	)
+	INNER JOIN item_type ON item.item_type_id = item_type.item_type_id
+`
+const varyingItemsConditions = `
+	item_slot.item_slot_when_building = 0
+	AND (
+		(
+			item_slot_state_variation > 0
+			AND item_state < item_type_max_state
+		) OR (
+			item_slot_state_variation < 0
+			AND item_state > 0
+		)
+	)
+`
+const varyingItemsGroups = `
+	GROUP BY
+		building.building_id,
+		item_slot.item_group_id
+	HAVING item_id = MIN(item_id)
+`
+
+// Variation of the state of a varying item per second
+const itemStateRate = `(
+	item_slot_state_variation
+	* building_size_x
+	* building_size_y
+	* building_size_z
+	* CASE
+		WHEN building_is_enabled = 0
+		THEN 0
+		WHEN building_state IS NULL
+		THEN 1
+		ELSE (
+			(building_state - building_type_min_state)
+			/ (building_type_max_state - building_type_min_state)
+		)
+	END
+)`
+
+// Inserts into temp_item_variation the items to update, with their new state, depending on building slots.
+// Uses the temp_online table to know which spaceships are connected, and applies to each one
+// the time passed since it has been simulated.
+func (store *SqliteStore) PutDataIntoItemVariation(now float64) {
+	err := store.conn.Exec(`
 		INSERT INTO temp_item_variation
 		SELECT
 			item_id,
 			CLAMP(
-				item_state + (
-					item_slot_state_variation
-					* ?1
-					* building_size_x
-					* building_size_y
-					* building_size_z
-					* CASE
-						WHEN building_is_enabled = 0
-						THEN 0
-						WHEN building_state IS NULL
-						THEN 1
-						ELSE (
-							(building_state - building_type_min_state)
-							/ (building_type_max_state - building_type_min_state)
-						)
-					END
-				),
+				item_state + ` + itemStateRate + ` * MAX(0, ?1 - spaceship.spaceship_last_simulated_at),
 				0,
 				item_type_max_state
 			) AS new_item_state
 		FROM temp_online
+		INNER JOIN spaceship ON temp_online.spaceship_id = spaceship.spaceship_id
 		INNER JOIN building ON temp_online.spaceship_id = building.spaceship_id
-		INNER JOIN building_type ON building.building_type_id = building_type.building_type_id
-		INNER JOIN item_slot ON building.building_type_id = item_slot.building_type_id
-		INNER JOIN item ON (
-			item.building_id = building.building_id
-			AND item.item_slot_group_id = item_slot.item_group_id
+		` + varyingItemsJoins + `
+		WHERE ` + varyingItemsConditions + `
+		` + varyingItemsGroups + `
+		;
+	`, now)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+// Same as PutDataIntoItemVariation, for one spaceship and the given time
+func (store *SqliteStore) PutSpaceShipDataIntoItemVariation(spaceShipId int64, passedTimeInSeconds float64) {
+	err := store.conn.Exec(`
+		INSERT INTO temp_item_variation
+		SELECT
+			item_id,
+			CLAMP(
+				item_state + ` + itemStateRate + ` * ?2,
+				0,
+				item_type_max_state
+			) AS new_item_state
+		FROM building
+		` + varyingItemsJoins + `
+		WHERE building.spaceship_id = ?1
+		AND ` + varyingItemsConditions + `
+		` + varyingItemsGroups + `
+		;
+	`, spaceShipId, passedTimeInSeconds)
+	if err != nil {
+		log.Panic(err)
+	}
+}
+
+// Returns the time (in seconds) before the first varying item of the spaceship becomes empty or full.
+// found is false if no item varies.
+func (store *SqliteStore) GetNextItemVariationEnd(spaceShipId int64) (seconds float64, found bool) {
+	s, err := store.conn.Prepare(`
+		SELECT MIN(
+			CASE
+				WHEN rate > 0
+				THEN (item_type_max_state - item_state) / rate
+				ELSE item_state / -rate
+			END
 		)
-		INNER JOIN item_type ON item.item_type_id = item_type.item_type_id
-		WHERE item_slot.item_slot_when_building = 0
-		AND (
-			(
-				item_slot_state_variation > 0
-				AND item_state < item_type_max_state
-			) OR (
-				item_slot_state_variation < 0
-				AND item_state > 0
-			)
+		FROM (
+			SELECT
+				item_id,
+				item_state,
+				item_type_max_state,
+				` + itemStateRate + ` AS rate
+			FROM building
+			` + varyingItemsJoins + `
+			WHERE building.spaceship_id = ?1
+			AND ` + varyingItemsConditions + `
+			` + varyingItemsGroups + `
 		)
-		GROUP BY
-			building.building_id,
-			item_slot.item_group_id
-		HAVING item_id = MIN(item_id)
+		WHERE rate <> 0
 		;
-	`, passedTimeInSeconds)
+	`)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	err = s.Select(func(s *sqlite.Stmt) error {
+		var isNull bool
+		seconds, isNull, err = s.ScanDouble(0); if err != nil { return err }
+		
+		found = !isNull
+		return nil
+	}, spaceShipId)
 	if err != nil {
 		log.Panic(err)
 	}
+	
+	return
 }
 
-func TruncateItemVariation() {
-	err := db.Exec(`
+func (store *SqliteStore) TruncateItemVariation() {
+	err := store.conn.Exec(`
 		DELETE FROM temp_item_variation;
 	`,)
 	if err != nil {
@@ -147,8 +230,8 @@ func TruncateItemVariation() {
 }
 
 // Updates the item states using values in temp_item_variation table
-func UpdateItemVariationFromTemp() {
-	err := db.Exec(`
+func (store *SqliteStore) UpdateItemVariationFromTemp() {
+	err := store.conn.Exec(`
 		UPDATE item
 		SET item_state = (
 			SELECT new_item_state
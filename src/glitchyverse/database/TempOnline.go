@@ -26,10 +26,11 @@ package db
 
 import (
 	"log"
+	"github.com/gwenn/gosqlite"
 )
 
-func createTableOnline() {
-	err := db.Exec(`
+func (store *SqliteStore) createTableOnline() {
+	err := store.conn.Exec(`
 		CREATE TEMPORARY TABLE temp_online (
 			user_id INTEGER,
 			spaceship_id INTEGER
@@ -40,8 +41,8 @@ func createTableOnline() {
 	}
 }
 
-func InsertUserOnline(userId int64, spaceShipId int64) {
-	err := db.Exec(`
+func (store *SqliteStore) InsertUserOnline(userId int64, spaceShipId int64) {
+	err := store.conn.Exec(`
 		INSERT INTO temp_online (
 			user_id,
 			spaceship_id
@@ -55,9 +56,8 @@ func InsertUserOnline(userId int64, spaceShipId int64) {
 	}
 }
 
-
-func DeleteUserOnline(userId int64) {
-	err := db.Exec(`
+func (store *SqliteStore) DeleteUserOnline(userId int64) {
+	err := store.conn.Exec(`
 		DELETE FROM temp_online
 		WHERE user_id = ?1
 		;
@@ -66,3 +66,28 @@ func DeleteUserOnline(userId int64) {
 		log.Panic(err)
 	}
 }
+
+func (store *SqliteStore) GetOnlineSpaceShipIds() []int64 {
+	s, err := store.conn.Prepare(`
+		SELECT DISTINCT spaceship_id
+		FROM temp_online
+		ORDER BY spaceship_id
+		;
+	`)
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	ids := make([]int64, 0)
+	err = s.Select(func(s *sqlite.Stmt) error {
+		id, _, err := s.ScanInt64(0); if err != nil { return err }
+		
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	return ids
+}
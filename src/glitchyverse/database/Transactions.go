@@ -30,16 +30,32 @@ import (
 	"errors"
 )
 
-// Callback must return true to commit, false to rollback
-func DeferredTransaction(f func() bool) {
-	err := db.Transaction(sqlite.Deferred, func(c *sqlite.Conn) error {
+var errRollback = errors.New("Rollback")
+
+// Callback must return true to commit, false to rollback.
+// If the callback panics, the transaction is rolled back before propagating the panic.
+func (store *SqliteStore) DeferredTransaction(f func() bool) {
+	var panicValue interface{}
+	
+	err := store.conn.Transaction(sqlite.Deferred, func(c *sqlite.Conn) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				panicValue = r
+				err = errRollback
+			}
+		}()
+		
 		if f() {
 			return nil
 		} else {
-			return errors.New("Rollback")
+			return errRollback
 		}
 	})
-	if err != nil {
-		log.Panic(err) // TODO is there an error in case of rollback (shouldn't be the case)
+	
+	if panicValue != nil {
+		panic(panicValue)
+	}
+	if err != nil && err != errRollback {
+		log.Panic(err)
 	}
 }
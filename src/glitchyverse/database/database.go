@@ -25,47 +25,31 @@
 package db
 
 import (
-	"math"
 	"log"
+	"time"
 	"github.com/gwenn/gosqlite"
 )
 
-var db *sqlite.Conn
-
+// Opens the SQLite database used by the package functions, see OpenMigratedSqliteStore
 func Open(filePath string) {
-	var err error
-	
-	db, err = sqlite.Open(filePath)
-	if err != nil {
-		log.Panic(err)
+	Use(OpenMigratedSqliteStore(filePath))
+}
+
+// Opens a SQLite database and applies the missing migrations.
+// The database is created if it doesn't exist.
+func OpenMigratedSqliteStore(filePath string) *SqliteStore {
+	store := OpenSqliteStore(filePath)
+	for _, m := range store.MigrateUp(0, time.Now().Unix()) {
+		log.Printf("Migration %04d_%s applied\n", m.Version, m.Name)
 	}
-	
-	// Creating custom functions
-	db.CreateScalarFunction("POW", 2, true, 0, func(ctx *sqlite.ScalarContext, nArg int) {
-		ctx.ResultDouble(math.Pow(ctx.Double(0), ctx.Double(1)))
-	}, func(pApp interface{}) {})
-	db.CreateScalarFunction("SQRT", 1, true, 0, func(ctx *sqlite.ScalarContext, nArg int) {
-		ctx.ResultDouble(math.Sqrt(ctx.Double(0)))
-	}, func(pApp interface{}) {})
-	db.CreateScalarFunction("CLAMP", 3, true, 0, func(ctx *sqlite.ScalarContext, nArg int) {
-		val := ctx.Double(0)
-		
-		if max := ctx.Double(2); val > max {
-			ctx.ResultDouble(max)
-		} else if min := ctx.Double(1); val < min {
-			ctx.ResultDouble(min)
-		} else {
-			ctx.ResultDouble(val)
-		}
-	}, func(pApp interface{}) {})
-	
-	// Creating temporary tables
-	// TODO add constraints (NOT NULL + primary key + FOREIGN KEY + CASCADE) ? What about performance ?
-	createTableEmptiedBuildings()
-	createTableItemVariation()
-	createTableOnline()
-	
-	// TODO set some useful pragmas (PRAGMA foo = "BAR")
+	return store
+}
+
+// Must be called when nothing uses the database anymore
+func Close() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current.Close()
 }
 
 func int64ToNull(x int64) interface{} {
@@ -76,6 +60,22 @@ func int64ToNull(x int64) interface{} {
 	}
 }
 
+func float64PtrToNull(x *float64) interface{} {
+	if x == nil {
+		return nil
+	} else {
+		return *x
+	}
+}
+
+func boolPtrToNull(x *bool) interface{} {
+	if x == nil {
+		return nil
+	} else {
+		return *x
+	}
+}
+
 func getNullInt64(s *sqlite.Stmt, i int) (*int64, error) {
 	data, isNull, err := s.ScanInt64(i)
 	var r *int64
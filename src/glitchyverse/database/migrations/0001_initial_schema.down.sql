@@ -0,0 +1,16 @@
+-- Removes everything, including the players data
+
+DROP TABLE IF EXISTS item;
+DROP TABLE IF EXISTS building;
+DROP TABLE IF EXISTS spaceship;
+DROP TABLE IF EXISTS user;
+DROP TABLE IF EXISTS item_slot;
+DROP TABLE IF EXISTS item_type_in_item_group;
+DROP TRIGGER IF EXISTS insert_default_group;
+DROP TABLE IF EXISTS item_type;
+DROP TABLE IF EXISTS item_group;
+DROP TABLE IF EXISTS building_type;
+DROP TABLE IF EXISTS building_type_category;
+DROP TABLE IF EXISTS body;
+DROP TABLE IF EXISTS body_type;
+DROP TABLE IF EXISTS chunk;
@@ -0,0 +1,158 @@
+-- Schema of the first versions of the game, and its reference data.
+-- Everything is conditional, so that this migration can be applied on the
+-- databases created before the migrations.
+
+CREATE TABLE IF NOT EXISTS [user] (
+	[user_id] INTEGER PRIMARY KEY ASC AUTOINCREMENT NOT NULL,
+	[user_name] TEXT NOT NULL UNIQUE,
+	[user_password] TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS [spaceship] (
+	[spaceship_id] INTEGER PRIMARY KEY ASC AUTOINCREMENT NOT NULL,
+	[user_id] INTEGER NOT NULL UNIQUE REFERENCES [user] ([user_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[spaceship_name] TEXT NOT NULL UNIQUE,
+	[spaceship_position_x] REAL NOT NULL DEFAULT(0),
+	[spaceship_position_y] REAL NOT NULL DEFAULT(0),
+	[spaceship_position_z] REAL NOT NULL DEFAULT(0),
+	[spaceship_rotation_x] REAL NOT NULL DEFAULT(0),
+	[spaceship_rotation_y] REAL NOT NULL DEFAULT(0),
+	[spaceship_rotation_z] REAL NOT NULL DEFAULT(0)
+);
+
+CREATE TABLE IF NOT EXISTS body_type (
+	body_type_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	body_type_name TEXT NOT NULL,
+	body_type_model TEXT NOT NULL,
+	body_type_max_visibility_distance REAL NOT NULL CHECK (body_type_max_visibility_distance > 0)
+);
+
+CREATE TABLE IF NOT EXISTS body (
+	body_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	body_type_id INTEGER NOT NULL REFERENCES body_type (body_type_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	body_parent_id INTEGER REFERENCES body (body_id) ON DELETE CASCADE ON UPDATE CASCADE,
+	body_position_x REAL NOT NULL,
+	body_position_y REAL NOT NULL,
+	body_position_z REAL NOT NULL,
+	body_radius REAL NOT NULL CHECK (body_radius > 0),
+	body_seed REAL
+);
+
+CREATE TABLE IF NOT EXISTS [building_type_category] (
+	[building_type_category_id] INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	[building_type_category_name] TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS [item_type] (
+	[item_type_id] INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	[item_type_name] TEXT NOT NULL,
+	[item_type_max_state] INTEGER NOT NULL CHECK(item_type_max_state >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS [item_group] (
+	[item_group_id] INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	[item_group_name] TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS [item_type_in_item_group] (
+	[item_type_id] INTEGER NOT NULL REFERENCES [item_type] ([item_type_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[item_group_id] INTEGER NOT NULL REFERENCES [item_group] ([item_group_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	PRIMARY KEY ([item_type_id], [item_group_id])
+);
+
+CREATE TABLE IF NOT EXISTS [item_slot] (
+	[building_type_id] INTEGER NOT NULL REFERENCES [building_type] ([building_type_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[item_group_id] INTEGER NOT NULL REFERENCES [item_group] ([item_group_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[item_slot_when_building] BOOLEAN NOT NULL,
+	[item_slot_maximum_amount] INTEGER NOT NULL CHECK(item_slot_maximum_amount > 0),
+	[item_slot_state_variation] REAL NOT NULL,
+	PRIMARY KEY ([building_type_id], [item_group_id], [item_slot_when_building])
+);
+
+-- Every item type is in the "any" group
+CREATE TRIGGER IF NOT EXISTS [insert_default_group] AFTER INSERT ON [item_type] FOR EACH ROW BEGIN
+	INSERT INTO item_type_in_item_group (item_type_id, item_group_id)
+	VALUES (NEW.item_type_id, 0);
+END;
+
+CREATE TABLE IF NOT EXISTS [item] (
+	[item_id] INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	[item_type_id] INTEGER NOT NULL REFERENCES [item_type] ([item_type_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[building_id] INTEGER NOT NULL REFERENCES [building] ([building_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[item_slot_group_id] INTEGER REFERENCES [item_group] ([item_group_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[item_state] REAL NOT NULL CHECK(item_state >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS [building] (
+	[building_id] INTEGER PRIMARY KEY ASC AUTOINCREMENT NOT NULL,
+	[spaceship_id] INTEGER NOT NULL REFERENCES [spaceship] ([spaceship_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[building_type_id] INTEGER NOT NULL REFERENCES [building_type] ([building_type_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[building_position_x] REAL NOT NULL,
+	[building_position_y] REAL NOT NULL,
+	[building_position_z] REAL NOT NULL,
+	[building_rotation_x] REAL NOT NULL DEFAULT(0),
+	[building_rotation_y] REAL NOT NULL DEFAULT(0),
+	[building_rotation_z] REAL NOT NULL DEFAULT(0),
+	[building_rotation_w] REAL NOT NULL DEFAULT(1),
+	[building_size_x] REAL NOT NULL DEFAULT(1),
+	[building_size_y] REAL NOT NULL DEFAULT(1),
+	[building_size_z] REAL NOT NULL DEFAULT(1),
+	[building_state] REAL,
+	[building_is_built] BOOLEAN NOT NULL,
+	[building_seed] TEXT,
+	[building_is_enabled] BOOLEAN NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS [building_type] (
+	[building_type_id] INTEGER PRIMARY KEY ASC AUTOINCREMENT NOT NULL,
+	[building_type_category_id] INTEGER REFERENCES [building_type_category] ([building_type_category_id]) ON UPDATE CASCADE ON DELETE CASCADE,
+	[building_type_name] TEXT NOT NULL,
+	[building_type_model] TEXT NOT NULL,
+	[building_type_is_gap] BOOLEAN NOT NULL,
+	[building_type_is_position_by_room_unit] BOOLEAN NOT NULL,
+	[building_type_default_state] REAL,
+	[building_type_min_state] REAL,
+	[building_type_max_state] REAL,
+	[building_type_is_sizeable] BOOLEAN NOT NULL,
+	[building_type_is_container] BOOLEAN NOT NULL,
+	[building_type_is_inside] BOOLEAN,
+	[building_type_can_exert_thrust] BOOLEAN NOT NULL,
+	[building_type_is_controllable] BOOLEAN NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS [chunk] (
+	[chunk_position_x] INTEGER NOT NULL,
+	[chunk_position_y] INTEGER NOT NULL,
+	[chunk_position_z] INTEGER NOT NULL,
+	PRIMARY KEY ([chunk_position_x], [chunk_position_y], [chunk_position_z])
+);
+
+-- Reference data
+
+INSERT OR IGNORE INTO building_type_category VALUES (1, 'Structure');
+INSERT OR IGNORE INTO building_type_category VALUES (2, 'Exploration');
+INSERT OR IGNORE INTO building_type_category VALUES (3, 'Storage');
+
+INSERT OR IGNORE INTO item_group VALUES (0, 'any');
+INSERT OR IGNORE INTO item_group VALUES (1, 'Battery');
+
+INSERT OR IGNORE INTO item_type VALUES (1, 'Small battery', 50); -- The trigger adds it to the "any" group
+INSERT OR IGNORE INTO item_type_in_item_group VALUES (1, 1);
+
+INSERT OR IGNORE INTO building_type VALUES (1, 1, 'Room', 'Room', 0, 1, NULL, NULL, NULL, 1, 1, 0, 0, 0);
+INSERT OR IGNORE INTO building_type VALUES (2, 1, 'Window', 'window.obj', 1, 1, NULL, NULL, NULL, 0, 0, NULL, 0, 0);
+INSERT OR IGNORE INTO building_type VALUES (3, 2, 'Propeller', 'Propeller', 0, 1, 0.0, -1.0, 2.0, 1, 0, 0, 1, 0);
+INSERT OR IGNORE INTO building_type VALUES (4, 1, 'Door', 'Door', 1, 1, 1.0, 0.0, 1.0, 0, 0, NULL, 0, 0);
+INSERT OR IGNORE INTO building_type VALUES (6, 2, 'Console', 'Console', 0, 1, NULL, NULL, NULL, 0, 0, 1, 0, 0);
+INSERT OR IGNORE INTO building_type VALUES (7, 3, 'Water tank', 'water_tank.obj', 0, 1, NULL, NULL, NULL, 0, 0, 1, 0, 0);
+INSERT OR IGNORE INTO building_type VALUES (8, 3, 'Shelf', 'shelf.obj', 0, 1, NULL, NULL, NULL, 0, 0, 1, 0, 0);
+INSERT OR IGNORE INTO building_type VALUES (12, 3, 'Power station', 'power_station.obj', 0, 1, NULL, NULL, NULL, 0, 0, 1, 0, 0);
+INSERT OR IGNORE INTO building_type VALUES (13, NULL, 'Character', 'Character', 0, 1, NULL, NULL, NULL, 0, 0, 1, 0, 1);
+INSERT OR IGNORE INTO building_type VALUES (14, 2, 'Hologram', 'Hologram', 0, 1, NULL, NULL, NULL, 0, 0, 1, 0, 0);
+
+INSERT OR IGNORE INTO item_slot VALUES (8, 0, 0, 50, 0.0);
+INSERT OR IGNORE INTO item_slot VALUES (12, 1, 0, 1, -1.0);
+INSERT OR IGNORE INTO item_slot VALUES (13, 0, 0, 5, 0.0);
+
+INSERT OR IGNORE INTO body_type VALUES (1, 'Star', 'Star', 300000.0);
+INSERT OR IGNORE INTO body_type VALUES (2, 'Planet', 'Planet', 100000.0);
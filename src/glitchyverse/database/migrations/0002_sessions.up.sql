@@ -0,0 +1,14 @@
+-- Sessions which can be resumed with a token. Only the hash of the token is stored.
+-- Dates are unix timestamps in seconds.
+-- The table may already exist, it was created when opening the database before the migrations.
+
+CREATE TABLE IF NOT EXISTS session (
+	session_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	user_id INTEGER NOT NULL REFERENCES user (user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+	session_token_hash TEXT NOT NULL UNIQUE,
+	session_label TEXT NOT NULL,
+	session_created_at INTEGER NOT NULL,
+	session_last_used_at INTEGER NOT NULL,
+	session_expires_at INTEGER NOT NULL,
+	session_is_revoked BOOLEAN NOT NULL DEFAULT(0)
+);
@@ -0,0 +1,16 @@
+-- Audit log of the login attempts, and lockouts of the accounts.
+-- Dates are unix timestamps in seconds.
+-- The tables may already exist, they were created when opening the database before the migrations.
+
+CREATE TABLE IF NOT EXISTS login_attempt (
+	login_attempt_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	login_attempt_user_name TEXT NOT NULL,
+	login_attempt_ip TEXT NOT NULL,
+	login_attempt_result TEXT NOT NULL,
+	login_attempt_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS login_lockout (
+	login_lockout_user_name TEXT PRIMARY KEY NOT NULL,
+	login_lockout_until INTEGER NOT NULL
+);
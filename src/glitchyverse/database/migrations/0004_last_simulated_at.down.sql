@@ -0,0 +1 @@
+ALTER TABLE spaceship DROP COLUMN spaceship_last_simulated_at;
@@ -0,0 +1,6 @@
+-- Date (unix timestamp in seconds, with decimals) until which the item production of each spaceship
+-- has been applied. The existing spaceships start from the date of the migration.
+
+ALTER TABLE spaceship ADD COLUMN spaceship_last_simulated_at REAL NOT NULL DEFAULT(0);
+
+UPDATE spaceship SET spaceship_last_simulated_at = CAST(strftime('%s', 'now') AS REAL);
@@ -0,0 +1,6 @@
+ALTER TABLE spaceship DROP COLUMN spaceship_angular_velocity_z;
+ALTER TABLE spaceship DROP COLUMN spaceship_angular_velocity_y;
+ALTER TABLE spaceship DROP COLUMN spaceship_angular_velocity_x;
+ALTER TABLE spaceship DROP COLUMN spaceship_velocity_z;
+ALTER TABLE spaceship DROP COLUMN spaceship_velocity_y;
+ALTER TABLE spaceship DROP COLUMN spaceship_velocity_x;
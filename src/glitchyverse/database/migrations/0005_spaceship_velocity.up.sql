@@ -0,0 +1,9 @@
+-- Velocity (units per second) and angular velocity (degrees per second) of the spaceships,
+-- which keep drifting while their owners are offline.
+
+ALTER TABLE spaceship ADD COLUMN spaceship_velocity_x REAL NOT NULL DEFAULT(0);
+ALTER TABLE spaceship ADD COLUMN spaceship_velocity_y REAL NOT NULL DEFAULT(0);
+ALTER TABLE spaceship ADD COLUMN spaceship_velocity_z REAL NOT NULL DEFAULT(0);
+ALTER TABLE spaceship ADD COLUMN spaceship_angular_velocity_x REAL NOT NULL DEFAULT(0);
+ALTER TABLE spaceship ADD COLUMN spaceship_angular_velocity_y REAL NOT NULL DEFAULT(0);
+ALTER TABLE spaceship ADD COLUMN spaceship_angular_velocity_z REAL NOT NULL DEFAULT(0);
@@ -0,0 +1,20 @@
+-- Back to euler angles, in degrees. 57.2957795130823 is 180 / pi.
+
+UPDATE spaceship
+SET
+	spaceship_rotation_x = 57.2957795130823 * ATAN2(
+		2 * (spaceship_rotation_x * spaceship_rotation_w - spaceship_rotation_y * spaceship_rotation_z),
+		1 - 2 * (spaceship_rotation_x * spaceship_rotation_x + spaceship_rotation_y * spaceship_rotation_y)
+	),
+	spaceship_rotation_y = 57.2957795130823 * ASIN(CLAMP(
+		2 * (spaceship_rotation_x * spaceship_rotation_z + spaceship_rotation_y * spaceship_rotation_w),
+		-1,
+		1
+	)),
+	spaceship_rotation_z = 57.2957795130823 * ATAN2(
+		2 * (spaceship_rotation_z * spaceship_rotation_w - spaceship_rotation_x * spaceship_rotation_y),
+		1 - 2 * (spaceship_rotation_y * spaceship_rotation_y + spaceship_rotation_z * spaceship_rotation_z)
+	)
+;
+
+ALTER TABLE spaceship DROP COLUMN spaceship_rotation_w;
@@ -0,0 +1,21 @@
+-- The rotations of the spaceships become quaternions (x, y, z, w), like the ones of the buildings.
+-- The euler angles (degrees, around x, then y, then z) are converted in place : the UPDATE reads the old values.
+-- 0.00872664625994 is pi / 360, to get the half angles in radians.
+
+ALTER TABLE spaceship ADD COLUMN spaceship_rotation_w REAL NOT NULL DEFAULT(1);
+
+UPDATE spaceship
+SET
+	spaceship_rotation_x =
+		SIN(spaceship_rotation_x * 0.00872664625994) * COS(spaceship_rotation_y * 0.00872664625994) * COS(spaceship_rotation_z * 0.00872664625994) +
+		COS(spaceship_rotation_x * 0.00872664625994) * SIN(spaceship_rotation_y * 0.00872664625994) * SIN(spaceship_rotation_z * 0.00872664625994),
+	spaceship_rotation_y =
+		COS(spaceship_rotation_x * 0.00872664625994) * SIN(spaceship_rotation_y * 0.00872664625994) * COS(spaceship_rotation_z * 0.00872664625994) -
+		SIN(spaceship_rotation_x * 0.00872664625994) * COS(spaceship_rotation_y * 0.00872664625994) * SIN(spaceship_rotation_z * 0.00872664625994),
+	spaceship_rotation_z =
+		COS(spaceship_rotation_x * 0.00872664625994) * COS(spaceship_rotation_y * 0.00872664625994) * SIN(spaceship_rotation_z * 0.00872664625994) +
+		SIN(spaceship_rotation_x * 0.00872664625994) * SIN(spaceship_rotation_y * 0.00872664625994) * COS(spaceship_rotation_z * 0.00872664625994),
+	spaceship_rotation_w =
+		COS(spaceship_rotation_x * 0.00872664625994) * COS(spaceship_rotation_y * 0.00872664625994) * COS(spaceship_rotation_z * 0.00872664625994) -
+		SIN(spaceship_rotation_x * 0.00872664625994) * SIN(spaceship_rotation_y * 0.00872664625994) * SIN(spaceship_rotation_z * 0.00872664625994)
+;
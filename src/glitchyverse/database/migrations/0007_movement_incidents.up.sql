@@ -0,0 +1,13 @@
+-- Log of the movement violations found by the anti-cheat validator, with the score of the user
+-- after the violation and the applied response. Dates are unix timestamps in seconds.
+
+CREATE TABLE movement_incident (
+	movement_incident_id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	movement_incident_user_id INTEGER NOT NULL,
+	movement_incident_kind TEXT NOT NULL,
+	movement_incident_score REAL NOT NULL,
+	movement_incident_response TEXT NOT NULL,
+	movement_incident_at INTEGER NOT NULL
+);
+
+CREATE INDEX movement_incident_user_id ON movement_incident (movement_incident_user_id);
@@ -0,0 +1,184 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package flight
+
+import (
+	"math"
+	"glitchyverse/model"
+)
+
+// Longest step of the integration of the accelerated movements
+const integrationStep = 0.1 // Seconds
+
+const degreesPerRadian = 180 / math.Pi
+
+// Front of the spaceships and of the propellers, before their rotation
+var front = model.Vec3{0, 0, -1}
+
+// Movement of a spaceship. Like in the client (www/js/classes/ship/SpaceShip.js),
+// the front of the spaceship is Orientation(rotation) applied to -z.
+type Motion struct {
+	Position        model.Vec3
+	Rotation        model.Quat
+	Velocity        model.Vec3 // Units per second
+	AngularVelocity model.Vec3 // Axis of the rotation, its length being the speed in degrees per second
+}
+
+// Building of a spaceship, with its items. The positions are in the grid of the spaceship.
+type Part struct {
+	Position model.Vec3
+	Size     model.Vec3
+	Mass     float64
+}
+
+// A building which can exert thrust, built and enabled. It pushes the spaceship towards its front.
+type Propeller struct {
+	Position model.Vec3
+	Size     model.Vec3
+	Rotation model.Quat
+	State    float64
+	MaxState float64
+}
+
+// Physical properties of a spaceship, in the coordinates of its grid
+type Ship struct {
+	Mass     float64
+	Center   model.Vec3 // Center of mass
+	Inertia  model.Vec3 // Moments of inertia around the axes passing through the center of mass
+	Force    model.Vec3 // Thrust of the propellers
+	Torque   model.Vec3
+	MaxSpeed float64 // 0 if there is no propeller
+}
+
+// Center of a building in the grid
+func center(position model.Vec3, size model.Vec3) model.Vec3 {
+	return position.Add(size.Sub(model.Vec3{1, 1, 1}).Scale(0.5))
+}
+
+func NewShip(parts []Part, propellers []Propeller, thrustPerPropellerUnit, maxSpeedPerPropellerUnit float64) Ship {
+	var ship Ship
+	
+	for _, p := range parts {
+		ship.Mass += p.Mass
+		ship.Center = ship.Center.Add(center(p.Position, p.Size).Scale(p.Mass))
+	}
+	if ship.Mass <= 0 {
+		return ship
+	}
+	ship.Center = ship.Center.Scale(1 / ship.Mass)
+	
+	// Each part is a uniform box
+	for _, p := range parts {
+		d := center(p.Position, p.Size).Sub(ship.Center)
+		for axis := 0 ; axis < 3 ; axis++ {
+			a, b := (axis + 1) % 3, (axis + 2) % 3
+			ship.Inertia[axis] += p.Mass * (d[a] * d[a] + d[b] * d[b] + (p.Size[a] * p.Size[a] + p.Size[b] * p.Size[b]) / 12)
+		}
+	}
+	
+	for _, p := range propellers {
+		volume := p.Size[0] * p.Size[1] * p.Size[2]
+		force := p.Rotation.Rotate(front).Scale(p.State * volume * thrustPerPropellerUnit)
+		
+		ship.Force = ship.Force.Add(force)
+		ship.Torque = ship.Torque.Add(center(p.Position, p.Size).Sub(ship.Center).Cross(force))
+		ship.MaxSpeed += maxSpeedPerPropellerUnit * p.MaxState * volume
+	}
+	
+	return ship
+}
+
+// Linear acceleration given by the propellers, in units per second²
+func (ship Ship) Acceleration() float64 {
+	if ship.Mass <= 0 {
+		return 0
+	}
+	return ship.Force.Length() / ship.Mass
+}
+
+// Angular acceleration given by the propellers, in degrees per second²
+func (ship Ship) AngularAcceleration() float64 {
+	var acceleration model.Vec3
+	for axis := 0 ; axis < 3 ; axis++ {
+		if ship.Inertia[axis] > 0 {
+			acceleration[axis] = ship.Torque[axis] / ship.Inertia[axis] * degreesPerRadian
+		}
+	}
+	return acceleration.Length()
+}
+
+// Rotation from the coordinates of the spaceship to the world
+func Orientation(rotation model.Quat) model.Quat {
+	return rotation.Conjugate()
+}
+
+func rotationOf(orientation model.Quat) model.Quat {
+	return orientation.Conjugate()
+}
+
+// Turns the orientation during the given time
+func turn(orientation model.Quat, angularVelocity model.Vec3, seconds float64) model.Quat {
+	speed := angularVelocity.Length()
+	if speed == 0 {
+		return orientation
+	}
+	return model.AxisAngle(angularVelocity.Scale(1 / speed), speed * seconds / degreesPerRadian).Mul(orientation).Normalize()
+}
+
+// Moves the spaceship during the given time, pushed by its propellers
+func (m *Motion) Accelerate(ship Ship, seconds float64) {
+	if ship.Mass <= 0 {
+		m.Drift(seconds)
+		return
+	}
+	
+	for seconds > 0 {
+		dt := math.Min(seconds, integrationStep)
+		seconds -= dt
+		orientation := Orientation(m.Rotation)
+		
+		acceleration := orientation.Rotate(ship.Force).Scale(1 / ship.Mass)
+		m.Position = m.Position.Add(m.Velocity.Scale(dt)).Add(acceleration.Scale(dt * dt / 2))
+		m.Velocity = m.Velocity.Add(acceleration.Scale(dt))
+		if speed := m.Velocity.Length(); ship.MaxSpeed > 0 && speed > ship.MaxSpeed {
+			m.Velocity = m.Velocity.Scale(ship.MaxSpeed / speed)
+		}
+		
+		var angularAcceleration model.Vec3
+		for axis := 0 ; axis < 3 ; axis++ {
+			if ship.Inertia[axis] > 0 {
+				angularAcceleration[axis] = ship.Torque[axis] / ship.Inertia[axis] * degreesPerRadian
+			}
+		}
+		m.AngularVelocity = m.AngularVelocity.Add(orientation.Rotate(angularAcceleration).Scale(dt))
+		m.Rotation = rotationOf(turn(orientation, m.AngularVelocity, dt))
+	}
+}
+
+// Moves the spaceship during the given time, without any thrust
+func (m *Motion) Drift(seconds float64) {
+	m.Position = m.Position.Add(m.Velocity.Scale(seconds))
+	m.Rotation = rotationOf(turn(Orientation(m.Rotation), m.AngularVelocity, seconds))
+}
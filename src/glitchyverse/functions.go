@@ -28,50 +28,41 @@ import (
 	"archive/tar"
 	"io/ioutil"
 	"os"
+	"log"
 	"time"
+	"context"
+	"syscall"
+	"os/signal"
+	"github.com/gorilla/websocket"
+	"glitchyverse/socket"
+	"glitchyverse/config"
 	"glitchyverse/database"
 	"glitchyverse/user"
+	"glitchyverse/space"
 	"fmt"
 	"net/http"
 )
 
-func GetConfig() (db string, www string, ip string, debug bool) {
-	db = "./glitchyverse.db" // Default
-	
-	ok := true
-	
-	for i := 1 ; i < len(os.Args) ; i++ {
-		if arg := os.Args[i] ; arg == "--debug" {
-			debug = true
-		} else {
-			if i == len(os.Args) - 1 {
-				ok = false
-				break
-			}
-			value := os.Args[i + 1]
-			i++
-			if arg == "-w" {
-				www = value
-			} else if arg == "-d" {
-				db = value
-			} else if arg == "-i" {
-				ip = value
-			} else {
-				ok = false
-				break
-			}
-		}
-	}
-	
-	if !ok {
-		fmt.Println("usage: glitchyverse [-d <database path>] [-w <www directory path>]" +
-		" [-i [<server ip or dns>]:[<port>]] [--debug]\n\n" +
-		"Debug mode is not safe and not optimal for a production")
+// Maximum time to wait for the connections and the http server after the countdown
+const shutdownTimeout = 10 * time.Second
+
+func GetConfig() *config.Config {
+	cfg, printConfig, err := config.Load(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println(config.Usage)
 		// TODO describe debug mode
 		os.Exit(1)
 	}
 	
-	return
+	if printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Panic(err)
+		}
+		os.Exit(0)
+	}
+	
+	return cfg
 }
 
 func addDirToTar(path string, pathInArchive string, tw *tar.Writer) (err error) {
@@ -110,41 +101,64 @@ func addDirToTar(path string, pathInArchive string, tw *tar.Writer) (err error)
     return
 }
 
-func startItemProductionThread() {
-	// Item production / consumption thread
-	itemVariationDelay := 3 * time.Second
+// Reloads the definitions on SIGHUP, after a content sync into the database of the running server
+func handleReload() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
 	
-	go func() {
-		lastUpdateTime := time.Now()
-		
-		for {
-			time.Sleep(itemVariationDelay)
-			
-			currentTime := time.Now()
-			passedTime := currentTime.Sub(lastUpdateTime)
-			lastUpdateTime = currentTime
-			
-			secondsPassed := passedTime.Seconds()
-			
-			db.DeferredTransaction(func() bool {
-				db.PutDataIntoItemVariation(secondsPassed)
-				db.UpdateItemVariationFromTemp()
-				
-				db.InsertIntoEmptiedBuildingsFromItemVariation()
-				db.UpdateEmptiedBuildingsFromTemp()
-				
-				user.LoopUsers(func(user *user.User) {
-					user.SendItemVariation()
-					user.SendDisabledBuildings()
-				})
-				
-				db.TruncateItemVariation()
-				db.TruncateEmptiedBuildings()
-				
-				return true
-			})
-		}
-	}()
+	for range signals {
+		user.LoadDefinitions()
+		fmt.Println("Definitions reloaded, hash", user.DefinitionsHash())
+	}
+}
+
+// Waits for SIGINT or SIGTERM, then stops the server in this order :
+// new connections refused, clients warned, end of the simulation tick, connections closed
+// after their last messages, http server closed, end of the chunk generation, database closed
+// (unless some connections didn't end).
+// A second signal during the countdown stops the server immediately.
+func handleShutdown(server *http.Server, countdown time.Duration, stopSimulation func()) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	
+	sig := <-signals
+	fmt.Println("Received", sig, ", stopping server ...")
+	
+	socket.StopAccepting()
+	
+	user.SendMessageToAll("serverShutdown", struct{
+		Reason    string  `json:"reason"`
+		Countdown float64 `json:"countdown"` // Seconds before the disconnection
+	}{"The server is shutting down for maintenance.", countdown.Seconds()})
+	
+	select {
+		case <-time.After(countdown):
+		case <-signals:
+			fmt.Println("Countdown skipped")
+	}
+	
+	stopSimulation()
+	
+	connectionsClosed := socket.CloseConnections(websocket.CloseGoingAway, "serverShutdown", shutdownTimeout)
+	if !connectionsClosed {
+		log.Println("Some connections are still open after", shutdownTimeout)
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Println(err)
+	}
+	
+	space.WaitGeneration()
+	
+	// Their handlers may still be using the database, the process exit will release it
+	if connectionsClosed {
+		db.Close()
+	} else {
+		log.Println("Database left open for the remaining connections")
+	}
+	fmt.Println("Server stopped")
 }
 
 func AddNoCacheHeaders(w http.ResponseWriter) {
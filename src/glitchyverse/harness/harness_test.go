@@ -0,0 +1,75 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package harness
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Replays the transcripts of the repository, each one against a new server
+func TestTranscripts(t *testing.T) {
+	paths, err := filepath.Glob("../../../transcripts/*.transcript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("No transcript found")
+	}
+	
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			transcript, err := LoadTranscript(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			
+			server := Start()
+			defer server.Close()
+			if err := server.Replay(transcript); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestExpectWithoutPattern(t *testing.T) {
+	s, err := parseStep("phone < data_sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	captured := make(map[string]interface{})
+	if !match(s.data, []interface{}{map[string]interface{}{"id": 1.0}}, nil, captured) {
+		t.Error("A step without pattern must match any data")
+	}
+	
+	s, err = parseStep("alice < authQuery null")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match(s.data, "data", nil, captured) {
+		t.Error("The null pattern must only match null")
+	}
+}
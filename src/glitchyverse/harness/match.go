@@ -0,0 +1,120 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package harness
+
+import (
+	"math"
+	"errors"
+	"reflect"
+)
+
+func isVariable(s string) bool {
+	return len(s) > 1 && s[0] == '$'
+}
+
+// Checks that a decoded JSON value matches a pattern (see Transcript).
+// The values of the variables which are not known yet are put in captured.
+func match(pattern, value interface{}, variables, captured map[string]interface{}) bool {
+	switch p := pattern.(type) {
+		case string:
+			if p == "*" {
+				return true
+			}
+			if isVariable(p) {
+				if known, ok := variables[p]; ok {
+					return reflect.DeepEqual(known, value)
+				}
+				if known, ok := captured[p]; ok {
+					return reflect.DeepEqual(known, value)
+				}
+				captured[p] = value
+				return true
+			}
+			return p == value
+		case float64:
+			v, ok := value.(float64)
+			return ok && math.Abs(p - v) < 1e-9
+		case map[string]interface{}:
+			v, ok := value.(map[string]interface{})
+			if !ok {
+				return false
+			}
+			for key, keyPattern := range p {
+				keyValue, ok := v[key]
+				if !ok || !match(keyPattern, keyValue, variables, captured) {
+					return false
+				}
+			}
+			return true
+		case []interface{}:
+			v, ok := value.([]interface{})
+			if !ok || len(v) != len(p) {
+				return false
+			}
+			for i := range p {
+				if !match(p[i], v[i], variables, captured) {
+					return false
+				}
+			}
+			return true
+		default:
+			return p == value // bool or nil
+	}
+}
+
+// Replaces the variables of a decoded JSON value by their values
+func substitute(value interface{}, variables map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+		case string:
+			if !isVariable(v) {
+				return v, nil
+			}
+			known, ok := variables[v]
+			if !ok {
+				return nil, errors.New("Unknown variable : " + v)
+			}
+			return known, nil
+		case map[string]interface{}:
+			result := make(map[string]interface{}, len(v))
+			for key, keyValue := range v {
+				var err error
+				if result[key], err = substitute(keyValue, variables); err != nil {
+					return nil, err
+				}
+			}
+			return result, nil
+		case []interface{}:
+			result := make([]interface{}, len(v))
+			for i := range v {
+				var err error
+				if result[i], err = substitute(v[i], variables); err != nil {
+					return nil, err
+				}
+			}
+			return result, nil
+		default:
+			return v, nil
+	}
+}
@@ -0,0 +1,226 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package harness
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"strings"
+	"encoding/json"
+	"glitchyverse/client"
+	"glitchyverse/database"
+	"glitchyverse/protocol"
+)
+
+// Maximum time waited for an expected message
+var ExpectTimeout = 5 * time.Second
+
+type replay struct {
+	server    *Server
+	clients   map[string]*fakeClient
+	variables map[string]interface{}
+}
+
+// Client keeping the received messages until they are expected
+type fakeClient struct {
+	*client.Client
+	
+	mutex    sync.Mutex
+	received []receivedMessage
+	notify   chan struct{} // Receives a value when a message is added
+}
+
+type receivedMessage struct {
+	method string
+	data   interface{}
+}
+
+// Plays a transcript, and returns the first step which failed
+func (server *Server) Replay(transcript *Transcript) error {
+	r := &replay{
+		server:    server,
+		clients:   make(map[string]*fakeClient),
+		variables: make(map[string]interface{}),
+	}
+	defer func() {
+		for _, c := range r.clients {
+			c.Close()
+		}
+	}()
+	
+	for _, s := range transcript.steps {
+		if err := r.play(s); err != nil {
+			return fmt.Errorf("%s:%d: %v", transcript.Name, s.line, err)
+		}
+	}
+	return nil
+}
+
+func (r *replay) play(s step) error {
+	// The variables of the patterns are replaced while matching
+	data := s.data
+	if s.command != "expect" {
+		var err error
+		if data, err = substitute(s.data, r.variables); err != nil {
+			return err
+		}
+	}
+	
+	switch s.command {
+		case "tick":
+			r.server.Tick(time.Duration(s.seconds * float64(time.Second)))
+			return nil
+		case "item":
+			return r.insertItem(data, s.variable)
+	}
+	
+	if s.command == "connect" {
+		if _, ok := r.clients[s.client]; ok {
+			return fmt.Errorf("The client %s is already connected", s.client)
+		}
+		c, err := r.connect()
+		if err != nil {
+			return err
+		}
+		r.clients[s.client] = c
+		return nil
+	}
+	
+	c, ok := r.clients[s.client]
+	if !ok {
+		return fmt.Errorf("The client %s is not connected", s.client)
+	}
+	
+	switch s.command {
+		case "close":
+			delete(r.clients, s.client)
+			return c.Close()
+		case "send":
+			return checkReply(c.Call(s.method, data), s.code)
+		default:
+			return r.expect(c, s.method, s.data)
+	}
+}
+
+func (r *replay) connect() (*fakeClient, error) {
+	c, err := client.Dial(r.server.URL)
+	if err != nil {
+		return nil, err
+	}
+	
+	fake := &fakeClient{Client: c, notify: make(chan struct{}, 1)}
+	c.HandleAll(func(method string, rawData json.RawMessage) {
+		var data interface{}
+		json.Unmarshal(rawData, &data)
+		
+		fake.mutex.Lock()
+		fake.received = append(fake.received, receivedMessage{method, data})
+		fake.mutex.Unlock()
+		
+		select {
+			case fake.notify <- struct{}{}:
+			default:
+		}
+	})
+	return fake, nil
+}
+
+func checkReply(err error, expectedCode string) error {
+	if expectedCode == "" {
+		return err
+	}
+	
+	protocolErr, ok := err.(*protocol.Error)
+	if !ok {
+		return fmt.Errorf("Expected the error %s, got : %v", expectedCode, err)
+	}
+	if protocolErr.Code != expectedCode {
+		return fmt.Errorf("Expected the error %s, got : %v", expectedCode, protocolErr)
+	}
+	return nil
+}
+
+// Waits for a message, the previous ones are dropped
+func (r *replay) expect(c *fakeClient, method string, pattern interface{}) error {
+	timeout := time.After(ExpectTimeout)
+	skipped := make([]string, 0)
+	closed := false
+	
+	for {
+		c.mutex.Lock()
+		received := c.received
+		c.received = nil
+		c.mutex.Unlock()
+		
+		for i, m := range received {
+			captured := make(map[string]interface{})
+			if m.method == method && match(pattern, m.data, r.variables, captured) {
+				for name, value := range captured {
+					r.variables[name] = value
+				}
+				
+				// Keeping the next messages for the next steps
+				c.mutex.Lock()
+				c.received = append(received[i + 1:], c.received...)
+				c.mutex.Unlock()
+				return nil
+			}
+			
+			encoded, _ := json.Marshal(m.data)
+			skipped = append(skipped, m.method + " " + string(encoded))
+		}
+		
+		if closed {
+			return fmt.Errorf("Connection closed while waiting for %s : %v", method, c.Err())
+		}
+		
+		select {
+			case <-c.notify:
+			case <-c.Done():
+				closed = true // All the messages have been received, checking them one last time
+			case <-timeout:
+				return fmt.Errorf("No %s message matching the pattern, received :\n\t%s", method, strings.Join(skipped, "\n\t"))
+		}
+	}
+}
+
+func (r *replay) insertItem(data interface{}, variable string) error {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("The item must be an object")
+	}
+	
+	var values [4]float64
+	for i, name := range []string{"buildingId", "typeId", "slotGroupId", "state"} {
+		if values[i], ok = fields[name].(float64); !ok {
+			return fmt.Errorf("The item needs a number %s", name)
+		}
+	}
+	
+	id := db.InsertItem(int64(values[0]), int64(values[1]), int64(values[2]), values[3])
+	r.variables[variable] = float64(id) // Like the numbers of the decoded JSON
+	return nil
+}
@@ -0,0 +1,98 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package harness
+
+import (
+	"os"
+	"log"
+	"strings"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"net/http/httptest"
+	"time"
+	"github.com/gorilla/websocket"
+	"glitchyverse/anticheat"
+	"glitchyverse/clock"
+	"glitchyverse/database"
+	"glitchyverse/socket"
+	"glitchyverse/space"
+	"glitchyverse/user"
+)
+
+// Game server listening on a random local port, with a new temporary database.
+// Only one can run at a time, the packages of the server using global state.
+type Server struct {
+	URL   string        // WebSocket URL of the /play endpoint
+	Clock *clock.Manual // Simulation clock, only moving with Tick
+	
+	dir        string
+	http       *httptest.Server
+	simulation *user.Simulation
+}
+
+func Start() *Server {
+	dir, err := ioutil.TempDir("", "glitchyverse-harness")
+	if err != nil {
+		log.Panic(err)
+	}
+	
+	store := db.OpenSqliteStore(filepath.Join(dir, "glitchyverse.db"))
+	store.MigrateUp(0, time.Now().Unix())
+	db.Use(store)
+	user.LoadDefinitions()
+	user.ConfigureAntiCheat(anticheat.DefaultPolicy) // The user ids start again from 1
+	
+	simulationClock := clock.NewManual(time.Now())
+	user.SimulationClock = simulationClock
+	
+	mux := http.NewServeMux()
+	mux.HandleFunc("/play", socket.Handler)
+	
+	server := &Server{
+		Clock:      simulationClock,
+		dir:        dir,
+		http:       httptest.NewServer(mux),
+		simulation: user.NewSimulation(simulationClock),
+	}
+	server.URL = "ws" + strings.TrimPrefix(server.http.URL, "http") + "/play"
+	return server
+}
+
+// Disconnects the remaining clients, then stops the server and deletes the database
+func (server *Server) Close() {
+	socket.CloseConnections(websocket.CloseGoingAway, "serverShutdown", 5 * time.Second)
+	server.http.Close()
+	space.WaitGeneration()
+	db.Close()
+	os.RemoveAll(server.dir)
+	user.SimulationClock = clock.Real
+}
+
+// Advances the simulation clock, then simulates this time
+func (server *Server) Tick(d time.Duration) {
+	server.Clock.Advance(d)
+	server.simulation.Step()
+}
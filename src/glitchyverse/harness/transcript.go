@@ -0,0 +1,180 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package harness
+
+import (
+	"io"
+	"os"
+	"fmt"
+	"bufio"
+	"errors"
+	"strings"
+	"strconv"
+	"path/filepath"
+	"encoding/json"
+)
+
+// A transcript is a text file describing a game session, one step per line :
+//
+//	# Comment
+//	connect <client>                          opens a connection named <client>
+//	close <client>                            closes it
+//	<client> > <method> [<json>] [! <code>]   sends a request and waits for its reply, which must
+//	                                          be an acknowledgement, or the error <code> if given
+//	<client> < <method> [<json>]              waits for a message matching the JSON pattern (any data
+//	                                          if there is no pattern)
+//	tick <seconds>                            advances the simulation clock and runs the item production
+//	item <json> $<variable>                   creates an item (buildingId, typeId, slotGroupId and state)
+//
+// A "<" step skips the messages received before the expected one. In a JSON pattern, the objects
+// may have more keys than the pattern, and "*" matches anything. The strings like "$name" are
+// variables : the first pattern using a variable saves the value it matches, then the patterns
+// and the requests use this value.
+type Transcript struct {
+	Name  string
+	steps []step
+}
+
+type step struct {
+	line     int
+	command  string // connect, close, send, expect, tick or item
+	client   string
+	method   string
+	data     interface{} // Decoded JSON, can contain variables
+	code     string      // Error code expected by a send, "" if it must be accepted
+	variable string      // Saving the id of the item
+	seconds  float64
+}
+
+func LoadTranscript(path string) (*Transcript, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	
+	return ParseTranscript(filepath.Base(path), file)
+}
+
+func ParseTranscript(name string, r io.Reader) (*Transcript, error) {
+	transcript := &Transcript{Name: name}
+	scanner := bufio.NewScanner(r)
+	
+	for line := 1 ; scanner.Scan() ; line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		
+		s, err := parseStep(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, line, err)
+		}
+		s.line = line
+		transcript.steps = append(transcript.steps, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	
+	return transcript, nil
+}
+
+func parseStep(text string) (s step, err error) {
+	fields := strings.Fields(text)
+	
+	switch fields[0] {
+		case "connect", "close":
+			if len(fields) != 2 {
+				return s, errors.New("Expected : " + fields[0] + " <client>")
+			}
+			s.command, s.client = fields[0], fields[1]
+		case "tick":
+			if len(fields) != 2 {
+				return s, errors.New("Expected : tick <seconds>")
+			}
+			s.command = "tick"
+			if s.seconds, err = strconv.ParseFloat(fields[1], 64); err != nil {
+				return s, err
+			}
+		case "item":
+			s.command = "item"
+			var rest string
+			if s.data, rest, err = parseJson(strings.TrimPrefix(text, "item")); err != nil {
+				return s, err
+			}
+			if !isVariable(rest) {
+				return s, errors.New("Expected : item <json> $<variable>")
+			}
+			s.variable = rest
+		default:
+			if len(fields) < 3 || (fields[1] != ">" && fields[1] != "<") {
+				return s, errors.New("Unknown step : " + text)
+			}
+			s.client, s.method = fields[0], fields[2]
+			
+			// The JSON starts after the method name
+			rest := strings.TrimSpace(text[len(s.client):])
+			rest = strings.TrimSpace(rest[1:])[len(s.method):]
+			hasJson := strings.TrimSpace(rest) != ""
+			if s.data, rest, err = parseJson(rest); err != nil {
+				return s, err
+			}
+			
+			if fields[1] == "<" {
+				s.command = "expect"
+				if !hasJson {
+					s.data = "*"
+				}
+				if rest != "" {
+					return s, errors.New("Unexpected text after the JSON : " + rest)
+				}
+			} else {
+				s.command = "send"
+				if rest != "" {
+					if !strings.HasPrefix(rest, "!") {
+						return s, errors.New("Expected : ! <error code>")
+					}
+					s.code = strings.TrimSpace(rest[1:])
+				}
+			}
+	}
+	
+	return
+}
+
+// Decodes the JSON value at the beginning of a text (nil if there is none), and returns the rest of the text
+func parseJson(text string) (value interface{}, rest string, err error) {
+	text = strings.TrimSpace(text)
+	if text == "" || text[0] == '!' || text[0] == '$' {
+		return nil, text, nil
+	}
+	
+	decoder := json.NewDecoder(strings.NewReader(text))
+	if err = decoder.Decode(&value); err != nil {
+		return nil, "", err
+	}
+	return value, strings.TrimSpace(text[decoder.InputOffset():]), nil
+}
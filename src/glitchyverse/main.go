@@ -27,26 +27,77 @@ package main
 import (
 	"fmt"
 	"log"
+	"time"
 	"net/http"
 	"bytes"
 	"archive/tar"
 	"glitchyverse/socket"
+	"glitchyverse/anticheat"
+	"glitchyverse/content"
 	"glitchyverse/database"
+	"glitchyverse/user"
+	"glitchyverse/space"
 )
 
 
 func main() {
-	dbPath, wwwPath, ip, debug := GetConfig()
+	cfg := GetConfig()
+	if len(cfg.Command) > 0 {
+		runCommand(cfg)
+		return
+	}
+	
+	doubleLogin, err := user.ParseDoubleLoginPolicy(cfg.DoubleLogin)
+	if err != nil {
+		log.Panic(err)
+	}
+	user.OnDoubleLogin                     = doubleLogin
+	user.SpaceShipMaxSpeedPerPropellerUnit = cfg.Ship.MaxSpeedPerPropellerUnit
+	user.SpaceShipThrustPerPropellerUnit   = cfg.Ship.ThrustPerPropellerUnit
+	user.BuildingUnitMass                  = cfg.Ship.BuildingUnitMass
+	user.ItemMass                          = cfg.Ship.ItemMass
+	user.PositionTolerance                 = cfg.Ship.PositionTolerance
+	user.RotationTolerance                 = cfg.Ship.RotationTolerance
+	user.TeleportDistance                  = cfg.AntiCheat.TeleportDistance
+	user.AccelerationTolerance             = cfg.AntiCheat.AccelerationTolerance
+	user.AngularAccelerationTolerance      = cfg.AntiCheat.AngularAccelerationTolerance
+	user.SessionTokenLifetime              = cfg.Sessions.TokenLifetime.Duration
+	user.ConfigureAntiCheat(anticheat.Policy{
+		TeleportScore:     cfg.AntiCheat.TeleportScore,
+		AccelerationScore: cfg.AntiCheat.AccelerationScore,
+		RotationScore:     cfg.AntiCheat.RotationScore,
+		DecayPerSecond:    cfg.AntiCheat.DecayPerSecond,
+		RubberBandScore:   cfg.AntiCheat.RubberBandScore,
+		FreezeScore:       cfg.AntiCheat.FreezeScore,
+		KickScore:         cfg.AntiCheat.KickScore,
+		FreezeDuration:    cfg.AntiCheat.FreezeDuration.Duration,
+	})
+	space.Configure(cfg.Space.ChunkSize, cfg.Space.Seed)
 	
 	fmt.Println("Starting server ...") // TODO more messages in console
 	
-	db.Open(dbPath)
+	if cfg.Storage == "memory" {
+		pack, err := content.Load(cfg.Content)
+		if err != nil {
+			log.Panic(err)
+		}
+		if errs := pack.Validate(); len(errs) > 0 {
+			log.Panic(errs[0])
+		}
+		db.Use(content.NewMemoryStore(pack))
+	} else {
+		db.Open(cfg.Database)
+	}
+	db.DeleteExpiredSessions(time.Now().Unix())
+	db.DeleteLoginAudit(time.Now().Add(-cfg.Sessions.LoginAttemptRetention.Duration).Unix())
+	user.LoadDefinitions()
+	go handleReload()
 	
-	startItemProductionThread() // TODO use a init function ? where ?
+	stopSimulation := user.NewSimulation(user.SimulationClock).Start(cfg.Production.Delay.Duration) // TODO use a init function ? where ?
 	
 	// Handling normal files
-	fileServerHandler := http.FileServer(http.Dir("./www"))
-	if debug {
+	fileServerHandler := http.FileServer(http.Dir(cfg.Www))
+	if cfg.Debug {
 		fileServerHandler = (func(h http.Handler) http.HandlerFunc {
 			return func(w http.ResponseWriter, r *http.Request) {
 				AddNoCacheHeaders(w)
@@ -59,7 +110,7 @@ func main() {
 	// Creating content.tar in memory
 	buf := new(bytes.Buffer)
 	tw := tar.NewWriter(buf)
-	addDirToTar(wwwPath, "www", tw)
+	addDirToTar(cfg.Www, "www", tw)
 	if err := tw.Close(); err != nil {
 		log.Panic(err)
 	}
@@ -67,7 +118,7 @@ func main() {
 	
 	// Handling content.tar
 	http.HandleFunc("/content.tar", func(w http.ResponseWriter, r *http.Request) {
-		if debug {
+		if cfg.Debug {
 			AddNoCacheHeaders(w)
 		}
 		w.Write(tarContentBytes)
@@ -76,7 +127,14 @@ func main() {
 	// Handling WebSocket connection
 	http.HandleFunc("/play", socket.Handler)
 	
-	http.ListenAndServe(ip, nil) // TODO custom port
+	server := &http.Server{Addr: cfg.Listen}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Panic(err)
+		}
+	}()
+	
+	handleShutdown(server, cfg.Shutdown.Countdown.Duration, stopSimulation)
 }
 
 // TODO use debug mode (==> http header for client + static files without cache + database pragmas ...))
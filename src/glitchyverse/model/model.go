@@ -0,0 +1,94 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package model
+
+// The JSON tags are the field names of the messages sent to the clients
+
+type Spaceship struct {
+	Id              int64      `json:"id"`
+	Name            string     `json:"name"`
+	Position        Vec3       `json:"position"`
+	Rotation        Quat       `json:"rotation"`        // Inverse of the orientation of the spaceship in the world
+	Velocity        Vec3       `json:"velocity"`        // Units per second
+	AngularVelocity Vec3       `json:"angularVelocity"` // Degrees per second
+	Buildings       []Building `json:"buildings"`
+}
+
+type Building struct {
+	Id        int64   `json:"id"`
+	TypeId    int64   `json:"typeId"`
+	Position  Vec3    `json:"position"`
+	Rotation  Quat    `json:"rotation"`
+	Size      Vec3    `json:"size"`
+	State     float64 `json:"state"`
+	IsBuilt   bool    `json:"isBuilt"`
+	IsEnabled bool    `json:"isEnabled"`
+	Seed      *string `json:"seed"`
+	Items     []Item  `json:"items"`
+}
+
+type Item struct {
+	Id          int64   `json:"id"`
+	TypeId      int64   `json:"typeId"`
+	State       float64 `json:"state"`
+	BuildingId  int64   `json:"-"` // The items are sent in their building
+	SlotGroupId *int64  `json:"slotGroupId"`
+}
+
+type BuildingType struct {
+	Id                   int64      `json:"id"`
+	Name                 string     `json:"name"`
+	Category             *string    `json:"category"`
+	Model                string     `json:"model"`
+	IsGap                bool       `json:"isGap"`
+	DefaultState         float64    `json:"defaultState"`
+	IsSizeable           bool       `json:"isSizeable"`
+	IsContainer          bool       `json:"isContainer"`
+	IsInside             *bool      `json:"isInside"`
+	IsPositionByRoomUnit bool       `json:"isPositionByRoomUnit"`
+	MinState             float64    `json:"minState"`
+	MaxState             float64    `json:"maxState"`
+	CanExertThrust       bool       `json:"canExertThrust"`
+	IsControllable       bool       `json:"isControllable"`
+	Slots                []ItemSlot `json:"slots"`
+}
+
+type ItemSlot struct {
+	BuildingTypeId int64   `json:"-"` // The slots are sent in their building type
+	Group          int64   `json:"group"`
+	WhenBuilding   bool    `json:"whenBuilding"`
+	MaximumAmount  int64   `json:"maximumAmount"`
+	StateVariation float64 `json:"stateVariation"`
+}
+
+type Body struct {
+	Id       int64   `json:"id"`
+	TypeId   int64   `json:"-"`
+	ParentId *int64  `json:"-"`
+	Model    string  `json:"model"`
+	Position Vec3    `json:"position"`
+	Radius   float64 `json:"radius"`
+	Seed     float64 `json:"seed"`
+}
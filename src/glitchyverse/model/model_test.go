@@ -0,0 +1,158 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Marshals the value, checks the keys of the JSON object, then unmarshals it into a new value
+func roundTrip(t *testing.T, value interface{}, keys []string) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(object))
+	for key := range object {
+		got = append(got, key)
+	}
+	sort.Strings(got)
+	sort.Strings(keys)
+	if !reflect.DeepEqual(got, keys) {
+		t.Errorf("%T : got the keys %v, want %v", value, got, keys)
+	}
+	
+	decoded := reflect.New(reflect.TypeOf(value))
+	if err := json.Unmarshal(data, decoded.Interface()); err != nil {
+		t.Fatal(err)
+	}
+	return decoded.Elem().Interface()
+}
+
+func TestSpaceshipJson(t *testing.T) {
+	seed := "seed"
+	groupId := int64(2)
+	spaceship := Spaceship{
+		Id:              1,
+		Name:            "Ship",
+		Position:        Vec3{1, 2, 3},
+		Rotation:        Quat{0, 0.6, 0, 0.8},
+		Velocity:        Vec3{0, 0, -1},
+		AngularVelocity: Vec3{0, 5, 0},
+		Buildings:       []Building{{
+			Id:        3,
+			TypeId:    4,
+			Position:  Vec3{0, 0, 2},
+			Rotation:  Quat{0, 0, 0, 1},
+			Size:      Vec3{1, 1, 1},
+			State:     0.5,
+			IsBuilt:   true,
+			IsEnabled: true,
+			Seed:      &seed,
+			Items:     []Item{{Id: 5, TypeId: 6, State: 7, SlotGroupId: &groupId}},
+		}},
+	}
+	
+	got := roundTrip(t, spaceship, []string{"id", "name", "position", "rotation", "velocity", "angularVelocity", "buildings"})
+	if !reflect.DeepEqual(got, spaceship) {
+		t.Errorf("got %+v, want %+v", got, spaceship)
+	}
+}
+
+func TestBuildingJson(t *testing.T) {
+	building := Building{Id: 3, TypeId: 4, Position: Vec3{0, 0, 2}, Rotation: Quat{0, 0, 0, 1}, Size: Vec3{1, 1, 1}, Items: []Item{}}
+	
+	got := roundTrip(t, building, []string{"id", "typeId", "position", "rotation", "size", "state", "isBuilt", "isEnabled", "seed", "items"})
+	if !reflect.DeepEqual(got, building) {
+		t.Errorf("got %+v, want %+v", got, building)
+	}
+}
+
+func TestItemJson(t *testing.T) {
+	groupId := int64(2)
+	item := Item{Id: 5, TypeId: 6, State: 7, BuildingId: 8, SlotGroupId: &groupId}
+	
+	got := roundTrip(t, item, []string{"id", "typeId", "state", "slotGroupId"})
+	item.BuildingId = 0 // Not sent
+	if !reflect.DeepEqual(got, item) {
+		t.Errorf("got %+v, want %+v", got, item)
+	}
+}
+
+func TestBuildingTypeJson(t *testing.T) {
+	category := "Exploration"
+	isInside := false
+	buildingType := BuildingType{
+		Id:                   3,
+		Name:                 "Propeller",
+		Category:             &category,
+		Model:                "Propeller",
+		DefaultState:         0,
+		IsInside:             &isInside,
+		IsPositionByRoomUnit: true,
+		MinState:             -1,
+		MaxState:             2,
+		CanExertThrust:       true,
+		IsControllable:       true,
+		Slots:                []ItemSlot{{Group: 1, MaximumAmount: 2, StateVariation: -0.5}},
+	}
+	
+	got := roundTrip(t, buildingType, []string{
+		"id", "name", "category", "model", "isGap", "defaultState", "isSizeable", "isContainer", "isInside",
+		"isPositionByRoomUnit", "minState", "maxState", "canExertThrust", "isControllable", "slots",
+	})
+	if !reflect.DeepEqual(got, buildingType) {
+		t.Errorf("got %+v, want %+v", got, buildingType)
+	}
+}
+
+func TestItemSlotJson(t *testing.T) {
+	slot := ItemSlot{BuildingTypeId: 3, Group: 1, WhenBuilding: true, MaximumAmount: 2, StateVariation: -0.5}
+	
+	got := roundTrip(t, slot, []string{"group", "whenBuilding", "maximumAmount", "stateVariation"})
+	slot.BuildingTypeId = 0 // Not sent
+	if !reflect.DeepEqual(got, slot) {
+		t.Errorf("got %+v, want %+v", got, slot)
+	}
+}
+
+func TestBodyJson(t *testing.T) {
+	parentId := int64(1)
+	body := Body{Id: 2, TypeId: 2, ParentId: &parentId, Model: "Planet", Position: Vec3{1, 2, 3}, Radius: 4, Seed: 0.5}
+	
+	got := roundTrip(t, body, []string{"id", "model", "position", "radius", "seed"})
+	body.TypeId, body.ParentId = 0, nil // Not sent
+	if !reflect.DeepEqual(got, body) {
+		t.Errorf("got %+v, want %+v", got, body)
+	}
+}
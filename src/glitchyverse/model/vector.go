@@ -0,0 +1,108 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package model
+
+import (
+	"math"
+)
+
+// Position or size, on the x, y and z axis. Sent as an array.
+type Vec3 [3]float64
+
+// Rotation quaternion (x, y, z, w). Sent as an array.
+type Quat [4]float64
+
+func (v Vec3) Add(w Vec3) Vec3 {
+	return Vec3{v[0] + w[0], v[1] + w[1], v[2] + w[2]}
+}
+
+func (v Vec3) Sub(w Vec3) Vec3 {
+	return Vec3{v[0] - w[0], v[1] - w[1], v[2] - w[2]}
+}
+
+func (v Vec3) Scale(f float64) Vec3 {
+	return Vec3{v[0] * f, v[1] * f, v[2] * f}
+}
+
+func (v Vec3) Dot(w Vec3) float64 {
+	return v[0] * w[0] + v[1] * w[1] + v[2] * w[2]
+}
+
+func (v Vec3) Cross(w Vec3) Vec3 {
+	return Vec3{
+		v[1] * w[2] - v[2] * w[1],
+		v[2] * w[0] - v[0] * w[2],
+		v[0] * w[1] - v[1] * w[0],
+	}
+}
+
+func (v Vec3) Length() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+func (q Quat) Mul(r Quat) Quat {
+	return Quat{
+		q[3] * r[0] + q[0] * r[3] + q[1] * r[2] - q[2] * r[1],
+		q[3] * r[1] - q[0] * r[2] + q[1] * r[3] + q[2] * r[0],
+		q[3] * r[2] + q[0] * r[1] - q[1] * r[0] + q[2] * r[3],
+		q[3] * r[3] - q[0] * r[0] - q[1] * r[1] - q[2] * r[2],
+	}
+}
+
+// Inverse of a unit quaternion
+func (q Quat) Conjugate() Quat {
+	return Quat{-q[0], -q[1], -q[2], q[3]}
+}
+
+// Rotates the vector by the unit quaternion
+func (q Quat) Rotate(v Vec3) Vec3 {
+	u := Vec3{q[0], q[1], q[2]}
+	t := u.Cross(v).Scale(2)
+	return v.Add(t.Scale(q[3])).Add(u.Cross(t))
+}
+
+// Rotation of the angle (in radians) around the axis, which must be a unit vector
+func AxisAngle(axis Vec3, angle float64) Quat {
+	s := math.Sin(angle / 2)
+	return Quat{axis[0] * s, axis[1] * s, axis[2] * s, math.Cos(angle / 2)}
+}
+
+func (q Quat) Length() float64 {
+	return math.Sqrt(q[0] * q[0] + q[1] * q[1] + q[2] * q[2] + q[3] * q[3])
+}
+
+func (q Quat) Normalize() Quat {
+	length := q.Length()
+	if length == 0 {
+		return Quat{0, 0, 0, 1}
+	}
+	return Quat{q[0] / length, q[1] / length, q[2] / length, q[3] / length}
+}
+
+// Angle (in degrees) of the rotation between two unit quaternions
+func (q Quat) AngleTo(r Quat) float64 {
+	dot := math.Abs(q[0] * r[0] + q[1] * r[1] + q[2] * r[2] + q[3] * r[3])
+	return 2 * math.Acos(math.Min(1, dot)) * 180 / math.Pi
+}
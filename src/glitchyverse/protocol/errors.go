@@ -0,0 +1,77 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package protocol
+
+// Error codes sent to the clients
+const (
+	ErrorMalformedMessage = "malformedMessage" // The frame can't be read (protocol abuse, closes the connection)
+	ErrorTooManyErrors    = "tooManyErrors"    // Too many consecutive errors (protocol abuse, closes the connection)
+	ErrorUnknownMethod    = "unknownMethod"
+	ErrorInvalidData      = "invalidData"
+	ErrorNotAuthenticated = "notAuthenticated"
+	ErrorRefused          = "refused"          // The request is valid but has been refused by the game rules
+	ErrorInternal         = "internal"
+)
+
+// An error reported to the client with an "error" message. Only the fatal
+// ones (protocol-level abuse) close the connection after being reported.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Method  string `json:"method"`
+	Fatal   bool   `json:"-"`
+}
+
+func (err *Error) Error() string {
+	return err.Code + " (" + err.Method + ") : " + err.Message
+}
+
+func NewError(code string, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func NewFatalError(code string, message string) *Error {
+	return &Error{Code: code, Message: message, Fatal: true}
+}
+
+// True if the error comes from a wrong message, not from the game rules or the server.
+// Too many consecutive ones are a protocol abuse.
+func (err *Error) IsProtocolError() bool {
+	switch err.Code {
+		case ErrorMalformedMessage, ErrorUnknownMethod, ErrorInvalidData, ErrorNotAuthenticated:
+			return true
+	}
+	return false
+}
+
+// The request has been refused by the game rules (for example by a database constraint)
+func Refused(message string) *Error {
+	return NewError(ErrorRefused, message)
+}
+
+// The request data doesn't pass the validation
+func Invalid(message string) *Error {
+	return NewError(ErrorInvalidData, message)
+}
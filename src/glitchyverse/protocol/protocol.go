@@ -0,0 +1,116 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package protocol
+
+import (
+	"errors"
+	"strings"
+	"encoding/json"
+)
+
+// Protocol versions. Version 1 is the historical "method#json" format, version 2
+// wraps each message in a JSON envelope carrying a request id.
+const (
+	VersionLegacy   = 1
+	VersionEnvelope = 2
+	CurrentVersion  = VersionEnvelope
+)
+
+// WebSocket sub-protocols used to negotiate the version during the handshake.
+// A client which doesn't ask for any of them is considered as a legacy one.
+var Subprotocols = []string{"glitchyverse.v2"}
+
+// Methods used to answer to a client request
+const (
+	MethodAck   = "ack"
+	MethodError = "error"
+)
+
+type Envelope struct {
+	Version int             `json:"v"`
+	Id      int64           `json:"id,omitempty"`      // Request id, chosen by the client (0 = no reply expected)
+	ReplyTo int64           `json:"replyTo,omitempty"` // Id of the client request answered by this message
+	Method  string          `json:"method"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Returns the protocol version matching the sub-protocol selected during the handshake
+func VersionFromSubprotocol(subprotocol string) int {
+	switch subprotocol {
+		case "glitchyverse.v2":
+			return VersionEnvelope
+		default:
+			return VersionLegacy
+	}
+}
+
+// Reads a raw frame sent by a client using the given protocol version
+func Decode(version int, rawMessage []byte) (envelope Envelope, err error) {
+	if version == VersionLegacy {
+		stringMessage := string(rawMessage)
+		hashIndex := strings.Index(stringMessage, "#")
+		if hashIndex < 0 {
+			return envelope, errors.New("Missing method separator")
+		}
+		
+		envelope.Version = VersionLegacy
+		envelope.Method = stringMessage[:hashIndex]
+		envelope.Data = json.RawMessage(stringMessage[hashIndex + 1:])
+	} else {
+		err = json.Unmarshal(rawMessage, &envelope)
+		if err != nil {
+			return
+		}
+		if envelope.Version != version {
+			return envelope, errors.New("Unexpected protocol version")
+		}
+	}
+	
+	if envelope.Method == "" {
+		return envelope, errors.New("Missing method name")
+	}
+	
+	return
+}
+
+// Creates a raw frame to send to a client using the given protocol version.
+// replyTo is ignored by the legacy protocol.
+func Encode(version int, replyTo int64, method string, data interface{}) ([]byte, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	
+	if version == VersionLegacy {
+		return []byte(method + "#" + string(jsonData)), nil
+	}
+	
+	return json.Marshal(Envelope{
+		Version: version,
+		ReplyTo: replyTo,
+		Method:  method,
+		Data:    json.RawMessage(jsonData),
+	})
+}
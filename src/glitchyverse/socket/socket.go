@@ -25,28 +25,53 @@
 package socket
 
 import (
-	"fmt"
 	"log"
+	"math"
+	"sync"
+	"time"
 	"runtime"
 	"net/http"
-	"strings"
 	"github.com/gorilla/websocket"
 	"glitchyverse/user"
+	"glitchyverse/model"
+	"glitchyverse/protocol"
 	"encoding/json"
 	"reflect"
-	"errors"
 )
 
-var sockets = make(map[*user.User]bool)
+var upgrader = websocket.Upgrader{
+	Subprotocols: protocol.Subprotocols,
+}
 
-var upgrader = websocket.Upgrader{}
+const (
+	maxMessageSize          = 64 * 1024 // Bigger messages close the connection
+	maxConsecutiveErrors    = 20        // Protocol errors (see protocol.Error.IsProtocolError) in a row, the connection is closed when reached
+	rotationLengthTolerance = 0.01      // Maximum difference between 1 and the length of a rotation quaternion
+)
+
+var (
+	connectionsMutex sync.Mutex
+	connections      sync.WaitGroup // Handlers still running
+	acceptingClosed  bool           // True once the server is shutting down
+)
 
 type messageHandler struct {
 	funcValue reflect.Value
 	paramType reflect.Type
+	isPublic  bool // Can be called without being authenticated
 }
 
 func Handler(w http.ResponseWriter, r *http.Request) {
+	connectionsMutex.Lock()
+	if acceptingClosed {
+		connectionsMutex.Unlock()
+		http.Error(w, "The server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	connections.Add(1)
+	connectionsMutex.Unlock()
+	defer connections.Done()
+	
 	ws, err := upgrader.Upgrade(w, r, nil)
 	
 	if err != nil {
@@ -56,50 +81,119 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	user := user.NewUser(ws)
+	ws.SetReadLimit(maxMessageSize)
+	
+	user := user.NewUser(ws, protocol.VersionFromSubprotocol(ws.Subprotocol()))
+	user.UserAgent = r.UserAgent()
 	defer user.Disconnect()
 	
+	consecutiveErrors := 0
 	for {
 		_, rawMessage, err := ws.ReadMessage()
 		if err != nil {
 			break
 		}
 		
-		err = handleMessage(user, rawMessage)
-		if(err != nil) {
-			log.Println(err)
+		// The refused requests are valid ones, they don't count
+		requestId, protocolErr := handleMessage(user, rawMessage)
+		if protocolErr == nil || !protocolErr.IsProtocolError() {
+			consecutiveErrors = 0
+		} else {
+			consecutiveErrors++
+			if consecutiveErrors >= maxConsecutiveErrors && !protocolErr.Fatal {
+				protocolErr = protocol.NewFatalError(protocol.ErrorTooManyErrors, "Too many consecutive errors")
+			}
+		}
+		
+		user.SendReply(requestId, protocolErr)
+		
+		if protocolErr != nil && protocolErr.Fatal {
+			log.Println(protocolErr)
+			user.SetCloseReason(websocket.ClosePolicyViolation, protocolErr.Code)
+			break
+		}
+		if user.MustClose() {
 			break
 		}
 	}
 }
 
-func handleMessage(user *user.User, rawMessage []byte) (err error) {
+// Refuses the new connections, the current ones are not affected
+func StopAccepting() {
+	connectionsMutex.Lock()
+	acceptingClosed = true
+	connectionsMutex.Unlock()
+}
+
+// Disconnects every user once its current request is handled, and waits until all the
+// connections are closed (and their messages sent), or until the timeout.
+// Returns false if some connections are still open. StopAccepting must be called before.
+func CloseConnections(closeCode int, text string, timeout time.Duration) bool {
+	for _, u := range user.Users.Snapshot(false) {
+		u.Interrupt(closeCode, text)
+	}
+	
+	done := make(chan struct{})
+	go func() {
+		connections.Wait()
+		close(done)
+	}()
+	
+	select {
+		case <-done:
+			return true
+		case <-time.After(timeout):
+			return false
+	}
+}
+
+// Returns the id of the handled request, and the error to report to the client if it failed
+func handleMessage(user *user.User, rawMessage []byte) (requestId int64, protocolErr *protocol.Error) {
+	var methodName string
+	
 	defer func() {
 		if r := recover(); r != nil {
 			trace := make([]byte, 1024)
 			runtime.Stack(trace, true)
+			log.Printf("%v\n===== Stack trace : =====\n%s", r, trace)
 			
-			err = errors.New(fmt.Sprintf("%v\n===== Stack trace : =====\n%s", r, trace))
+			protocolErr = protocol.NewError(protocol.ErrorInternal, "Internal server error")
+		}
+		
+		if protocolErr != nil {
+			protocolErr.Method = methodName
 		}
 	}()
 	
-	stringMessage := string(rawMessage)
-	hashIndex := strings.Index(stringMessage, "#")
-	methodName := stringMessage[:hashIndex]
-	rawData := []byte(stringMessage[hashIndex + 1:])
+	envelope, err := protocol.Decode(user.Protocol, rawMessage)
+	if err != nil {
+		return 0, protocol.NewFatalError(protocol.ErrorMalformedMessage, err.Error())
+	}
+	requestId = envelope.Id
+	methodName = envelope.Method
 	
-	method, ok := methods[methodName]
+	method, ok := methods[envelope.Method]
 	if !ok {
-		return errors.New("Unknown method : " + methodName)
+		return requestId, protocol.NewError(protocol.ErrorUnknownMethod, "Unknown method : " + envelope.Method)
+	}
+	
+	if !method.isPublic && user.UserId <= 0 {
+		return requestId, protocol.NewError(protocol.ErrorNotAuthenticated, "Authentication required")
 	}
 	
 	paramValue := reflect.New(method.paramType)
-	err = json.Unmarshal(rawData, paramValue.Interface())
+	err = json.Unmarshal(envelope.Data, paramValue.Interface())
 	if err != nil {
-		return
+		return requestId, protocol.Invalid(err.Error())
 	}
 	
-	method.funcValue.Call([]reflect.Value{reflect.ValueOf(user), paramValue.Elem()})
+	// The error returned by the method means that the request has been refused, not that the message is invalid
+	results := method.funcValue.Call([]reflect.Value{reflect.ValueOf(user), paramValue.Elem()})
+	if err, _ := results[0].Interface().(error); err != nil {
+		if protocolErr, ok = err.(*protocol.Error); !ok {
+			protocolErr = protocol.Refused(err.Error())
+		}
+	}
 	
 	return
 }
@@ -107,30 +201,84 @@ func handleMessage(user *user.User, rawMessage []byte) (err error) {
 var methods = make(map[string]messageHandler)
 
 func init() {
-	addMethod := func(name string, method reflect.Value) {
+	addMethod := func(name string, isPublic bool, method reflect.Value) {
 		methods[name] = messageHandler {
 			funcValue: method,
 			paramType: method.Type().In(1),
+			isPublic:  isPublic,
 		}
 	}
 	
-	addMethod("authAnswer", reflect.ValueOf(func(user *user.User, data *struct {
+	addMethod("authAnswer", true, reflect.ValueOf(func(user *user.User, data *struct {
 		Name string
 		Password string
+		DefinitionsHash string
 	}) (err error) {
-		user.Connect(data.Name, data.Password)
+		user.ClientDefinitionsHash = data.DefinitionsHash
+		if !user.Connect(data.Name, data.Password) {
+			err = protocol.Refused("Authentication failed")
+		}
+		return
+	}))
+	
+	addMethod("resumeSession", true, reflect.ValueOf(func(user *user.User, data *struct {
+		Token string
+		DefinitionsHash string
+	}) (err error) {
+		user.ClientDefinitionsHash = data.DefinitionsHash
+		if !user.ResumeSession(data.Token) {
+			err = protocol.Refused("Authentication failed")
+		}
+		return
+	}))
+	
+	addMethod("listSessionsQuery", false, reflect.ValueOf(func(user *user.User, data interface{}) (err error) {
+		user.SendSessions()
 		return
 	}))
 	
-	addMethod("updatePropellers", reflect.ValueOf(func(user *user.User, data *struct {
+	addMethod("revokeSessionQuery", false, reflect.ValueOf(func(user *user.User, data int64) (err error) {
+		return user.RevokeSession(data)
+	}))
+	
+	addMethod("registerQuery", true, reflect.ValueOf(func(user *user.User, data *struct {
+		Name          string
+		Password      string
+		SpaceshipName string
+		DefinitionsHash string
+	}) (err error) {
+		user.ClientDefinitionsHash = data.DefinitionsHash
+		return user.Register(data.Name, data.Password, data.SpaceshipName)
+	}))
+	
+	addMethod("changePasswordQuery", false, reflect.ValueOf(func(user *user.User, data *struct {
+		OldPassword string
+		NewPassword string
+	}) (err error) {
+		return user.ChangePassword(data.OldPassword, data.NewPassword)
+	}))
+	
+	addMethod("deleteAccountQuery", false, reflect.ValueOf(func(user *user.User, data *struct {
+		Password string
+	}) (err error) {
+		return user.DeleteAccount(data.Password)
+	}))
+	
+	addMethod("updatePropellers", false, reflect.ValueOf(func(user *user.User, data *struct {
 		Id int64
 		Power float64
+		Sequence int64
 	}) (err error) {
-		user.UpdatePropellers(data.Id, data.Power)
+		if data.Power < -1 || data.Power > 1 {
+			return protocol.Invalid("Power must be between -1 and 1")
+		}
+		if !user.UpdatePropellers(data.Id, data.Power, data.Sequence) {
+			err = protocol.Refused("The spaceship is frozen")
+		}
 		return
 	}))
 	
-	addMethod("updateDoors", reflect.ValueOf(func(user *user.User, data *struct {
+	addMethod("updateDoors", false, reflect.ValueOf(func(user *user.User, data *struct {
 		Id int64
 		State float64
 	}) (err error) {
@@ -138,42 +286,65 @@ func init() {
 		return
 	}))
 	
-	addMethod("updatePosition", reflect.ValueOf(func(user *user.User, data *struct {
-		Position [3]float64
-		Rotation [3]float64
+	addMethod("updatePosition", false, reflect.ValueOf(func(user *user.User, data *struct {
+		Position model.Vec3
+		Rotation model.Quat
+		Sequence int64
 	}) (err error) {
-		user.UpdatePosition(data.Position, data.Rotation)
+		if math.Abs(data.Rotation.Length() - 1) > rotationLengthTolerance {
+			return protocol.Invalid("Rotation must be a unit quaternion")
+		}
+		
+		// A corrected position is not an error, the user gets back the right one
+		user.UpdatePosition(data.Position, data.Rotation, data.Sequence)
 		return
 	}))
 	
 	// TODO better and unique way to update building, with boolean indicating if the building is freely updatable or not
 	
-	addMethod("buildQuery", reflect.ValueOf(func(user *user.User, data *struct {
+	addMethod("buildQuery", false, reflect.ValueOf(func(user *user.User, data *struct {
 		TypeId int64
-		Position [3]float64
-		Size     [3]float64
-		Rotation [4]float64
+		Position model.Vec3
+		Size     model.Vec3
+		Rotation model.Quat
 	}) (err error) {
-		user.AddBuilding(data.TypeId, data.Position, data.Size, data.Rotation)
+		for _, v := range data.Size {
+			if v < 1 || v != math.Floor(v) {
+				return protocol.Invalid("Size must be made of positive integers")
+			}
+		}
+		if math.Abs(data.Rotation.Length() - 1) > rotationLengthTolerance {
+			return protocol.Invalid("Rotation must be a unit quaternion")
+		}
+		
+		if !user.AddBuilding(data.TypeId, data.Position, data.Size, data.Rotation) {
+			err = protocol.Refused("The building can't be placed here")
+		}
 		return
 	}))
 	
-	addMethod("destroyQuery", reflect.ValueOf(func(user *user.User, data int64) (err error) {
-		user.DeleteBuilding(data)
+	addMethod("destroyQuery", false, reflect.ValueOf(func(user *user.User, data int64) (err error) {
+		if !user.DeleteBuilding(data) {
+			err = protocol.Refused("The building can't be destroyed")
+		}
 		return
 	}))
 	
-	addMethod("moveItemQuery", reflect.ValueOf(func(user *user.User, data *struct {
+	addMethod("moveItemQuery", false, reflect.ValueOf(func(user *user.User, data *struct {
 		ItemId      int64
 		BuildingId  int64
 		SlotGroupId int64
 	}) (err error) {
-		user.MoveItem(data.ItemId, data.BuildingId, data.SlotGroupId)
+		if !user.MoveItem(data.ItemId, data.BuildingId, data.SlotGroupId) {
+			err = protocol.Refused("The item can't be moved here")
+		}
 		return
 	}))
 	
-	addMethod("achieveBuildingQuery", reflect.ValueOf(func(user *user.User, data int64) (err error) {
-		user.AchieveBuilding(data)
+	addMethod("achieveBuildingQuery", false, reflect.ValueOf(func(user *user.User, data int64) (err error) {
+		if !user.AchieveBuilding(data) {
+			err = protocol.Refused("The building requirements are not met")
+		}
 		return
 	}))
 }
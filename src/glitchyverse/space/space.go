@@ -26,15 +26,19 @@ package space
 
 import (
 	"log"
+	"sync"
 	"math/rand"
+	"glitchyverse/model"
 	"glitchyverse/database"
 )
 
-// TODO replace all [3]float64 arrays with a type + int64 by id type
-
-const (
+// Can be changed with Configure
+var (
 	seed = int16(1234) // TODO remove seed ?
 	chunkSize = int64(100000)
+)
+
+const (
 	clientChunkRadiusVisibility = 1.0 // TODO determine it based on the max distance visibility from db ?
 	chunkStarProbability = 0.8
 	
@@ -47,46 +51,34 @@ const (
 )
 
 type chunkGeneratorQueueMember interface {
-	GetPosition() [3]float64
-	SendSpaceContent(data []Body)
-}
-
-type Body struct {
-	Id        int64      `json:"id"`
-	TypeModel string     `json:"model"`
-	Position  [3]float64 `json:"position"`
-	Radius    float64    `json:"radius"`
-	Seed      float64    `json:"seed"`
+	GetPosition() model.Vec3
+	SendSpaceContent(data []model.Body)
 }
 
 var chunkGeneratorQueue = make(chan chunkGeneratorQueueMember)
+var pendingGenerations sync.WaitGroup // Requests not handled yet by the chunk generator
 
 // TODO use star and planet max visibility attribute instead of just sending chunks
 
 // TODO multiple threads ?
 func init() {
 	go func() {
-		visibility := clientChunkRadiusVisibility * chunkSize
-		
 		var user chunkGeneratorQueueMember
 		for {
 			user = <-chunkGeneratorQueue
+			visibility := visibleDistance()
 			pos := user.GetPosition()
-			rpos := [3]int64 {
-				int64(pos[0]),
-				int64(pos[1]),
-				int64(pos[2]),
-			}
+			chunk := ChunkOf(pos)
 			
 			minCoords := [3]int64 {
-				rpos[0] - rpos[0] % chunkSize - visibility,
-				rpos[1] - rpos[1] % chunkSize - visibility,
-				rpos[2] - rpos[2] % chunkSize - visibility,
+				chunk[0] - visibility,
+				chunk[1] - visibility,
+				chunk[2] - visibility,
 			}
 			maxCoords := [3]int64 {
-				rpos[0] - rpos[0] % chunkSize + visibility,
-				rpos[1] - rpos[1] % chunkSize + visibility,
-				rpos[2] - rpos[2] % chunkSize + visibility,
+				chunk[0] + visibility,
+				chunk[1] + visibility,
+				chunk[2] + visibility,
 			}
 			
 			// To check if a chunk has already been generated
@@ -100,7 +92,7 @@ func init() {
 				for y := minCoords[1] ; y <= maxCoords[1] ; y += chunkSize {
 					for z := minCoords[2] ; z <= maxCoords[2] ; z += chunkSize {
 						currentChunkPosition := [3]int64{x, y, z}
-						if generatedChunks[currentGeneratedChunkIndex] == currentChunkPosition {
+						if currentGeneratedChunkIndex < len(generatedChunks) && generatedChunks[currentGeneratedChunkIndex] == currentChunkPosition {
 							currentGeneratedChunkIndex += 1 // Already generated, just going to next chunk
 						} else {
 							generateChunk(currentChunkPosition)
@@ -109,38 +101,71 @@ func init() {
 				}
 			}
 			
-			user.SendSpaceContent(getVisibleBodies(pos))
+			user.SendSpaceContent(db.GetVisibleBodies(pos))
+			pendingGenerations.Done()
 		}
 	}()
 }
 
+// Changes the generation parameters. Must be called before the first chunk generation, and
+// the values must not change for an existing database (the generated chunks would not match).
+func Configure(newChunkSize int64, newSeed int16) {
+	chunkSize = newChunkSize
+	seed = newSeed
+}
+
+// Position of the chunk containing the position
+func ChunkOf(position model.Vec3) [3]int64 {
+	var chunk [3]int64
+	for i := range chunk {
+		p := int64(position[i])
+		chunk[i] = p - p % chunkSize
+	}
+	return chunk
+}
+
+// Maximum difference between the coordinates of a chunk and the ones of the chunks visible from it
+func visibleDistance() int64 {
+	return int64(clientChunkRadiusVisibility * float64(chunkSize))
+}
+
+// True if the chunk of b is one of the chunks visible from the chunk of a, and conversely
+func AreInVisibleChunks(a, b model.Vec3) bool {
+	visibility := visibleDistance()
+	chunkA, chunkB := ChunkOf(a), ChunkOf(b)
+	for i := range chunkA {
+		if d := chunkA[i] - chunkB[i]; d > visibility || d < -visibility {
+			return false
+		}
+	}
+	return true
+}
+
+// Positions of the chunks visible from the chunk of the position, itself included (see AreInVisibleChunks)
+func VisibleChunks(position model.Vec3) [][3]int64 {
+	radius := visibleDistance() / chunkSize
+	chunk := ChunkOf(position)
+	
+	chunks := make([][3]int64, 0, (2 * radius + 1) * (2 * radius + 1) * (2 * radius + 1))
+	for x := -radius ; x <= radius ; x++ {
+		for y := -radius ; y <= radius ; y++ {
+			for z := -radius ; z <= radius ; z++ {
+				chunks = append(chunks, [3]int64{chunk[0] + x * chunkSize, chunk[1] + y * chunkSize, chunk[2] + z * chunkSize})
+			}
+		}
+	}
+	return chunks
+}
+
 func SendVisibleChunks(user chunkGeneratorQueueMember) {
+	pendingGenerations.Add(1)
 	chunkGeneratorQueue <- user
 }
 
-func getVisibleBodies(position [3]float64) []Body {
-	bodies := make([]Body, 0)
-	
-	db.GetVisibleBodies(position, func(
-		id, typeId int64,
-		parentId *int64,
-		position [3]float64,
-		radius float64,
-		seed float64,
-		typeName string,
-		typeModel string,
-		maxVisivilityDistance float64,
-	) {
-		bodies = append(bodies, Body {
-			Id       : id,
-			TypeModel: typeModel,
-			Position : position,
-			Radius   : radius,
-			Seed     : seed,
-		})
-	},)
-	
-	return bodies
+// Waits until the chunks asked with SendVisibleChunks are generated and sent.
+// The database must not be closed before.
+func WaitGeneration() {
+	pendingGenerations.Wait()
 }
 
 func generateChunk(position [3]int64) {
@@ -148,7 +173,7 @@ func generateChunk(position [3]int64) {
 		log.Panicf("Invalid chunk position : %v", position)
 	}
 	
-	db.DeferredTransaction(func() bool {
+	db.DeferredTransaction(func(tx db.Store) bool {
 		// Generating chunk seed from seed and position
 		var chunkSeed int64 = 0
 		chunkSeed = chunkSeed | int64(seed)
@@ -159,24 +184,24 @@ func generateChunk(position [3]int64) {
 		rng := rand.New(rand.NewSource(chunkSeed))
 		
 		if rng.Float64() < chunkStarProbability {
-			generateStellarSystem(rng, [3]float64 {
+			generateStellarSystem(tx, rng, model.Vec3 {
 				float64(position[0]) + float64(rng.Int31n(int32(chunkSize))),
 				float64(position[1]) + float64(rng.Int31n(int32(chunkSize))),
 				float64(position[2]) + float64(rng.Int31n(int32(chunkSize))),
 			})
 		}
 		
-		db.InsertChunk(position)
+		tx.InsertChunk(position)
 		
 		return true
 	},)
 }
 
-func generateStellarSystem(rng *rand.Rand, position [3]float64) {
+func generateStellarSystem(store db.Store, rng *rand.Rand, position model.Vec3) {
 	// Creating star
 	starRadius := starRadiusMin + float64(rng.Int31n(starRadiusMax - starRadiusMin))
 	
-	starId := db.InsertBody(
+	starId := store.InsertBody(
 		1, // Type 1 = Star
 		0,
 		position,
@@ -187,7 +212,7 @@ func generateStellarSystem(rng *rand.Rand, position [3]float64) {
 	// Creating planets
 	planetCount := planetCountMin + rng.Int31n(planetCountMax - planetCountMin)
 	for i := int32(0) ; i < planetCount ; i++ {
-		var planetPosition [3]float64
+		var planetPosition model.Vec3
 		for axis := 0 ; axis < 3 ; axis++ {
 			symbol := float64(+1)
 			if rng.Float32() < 0.5 {
@@ -198,7 +223,7 @@ func generateStellarSystem(rng *rand.Rand, position [3]float64) {
 			planetPosition[axis] = (position[axis] + (starRadius * symbol * planetDistance))
 		}
 		
-		db.InsertBody(
+		store.InsertBody(
 			2, // Type 2 = Planet
 			starId,
 			planetPosition,
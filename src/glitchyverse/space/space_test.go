@@ -0,0 +1,102 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package space
+
+import (
+	"testing"
+	"glitchyverse/content"
+	"glitchyverse/database"
+	"glitchyverse/model"
+)
+
+type fakeMember struct {
+	position model.Vec3
+	bodies   []model.Body
+}
+
+func (m *fakeMember) GetPosition() model.Vec3 {
+	return m.position
+}
+
+func (m *fakeMember) SendSpaceContent(data []model.Body) {
+	m.bodies = data
+}
+
+// A database bootstrapped from the migrations has no generated chunk
+func TestGenerationWithoutChunks(t *testing.T) {
+	pack, err := content.Load("../../../content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Use(content.NewMemoryStore(pack))
+	
+	member := &fakeMember{position: model.Vec3{1, 2, 3}}
+	SendVisibleChunks(member)
+	WaitGeneration()
+	
+	visibility := visibleDistance()
+	chunks := db.GetGeneratedChunks([3]int64{-visibility, -visibility, -visibility}, [3]int64{visibility, visibility, visibility})
+	if len(chunks) != 27 {
+		t.Fatalf("got %d generated chunks, want 27", len(chunks))
+	}
+	if member.bodies == nil {
+		t.Fatal("the space content has not been sent")
+	}
+	
+	// The chunks are generated only once
+	SendVisibleChunks(member)
+	WaitGeneration()
+	chunks = db.GetGeneratedChunks([3]int64{-visibility, -visibility, -visibility}, [3]int64{visibility, visibility, visibility})
+	if len(chunks) != 27 {
+		t.Fatalf("got %d generated chunks after a second generation, want 27", len(chunks))
+	}
+}
+
+// The visible chunks are the ones of the positions for which AreInVisibleChunks is true
+func TestVisibleChunks(t *testing.T) {
+	for _, position := range []model.Vec3{{1, 2, 3}, {250000, 120000, 99999}} {
+		chunks := VisibleChunks(position)
+		if len(chunks) != 27 {
+			t.Errorf("%v : got %d visible chunks, want 27", position, len(chunks))
+		}
+		
+		visible := make(map[[3]int64]bool)
+		for _, chunk := range chunks {
+			visible[chunk] = true
+			if !AreInVisibleChunks(position, model.Vec3{float64(chunk[0]), float64(chunk[1]), float64(chunk[2])}) {
+				t.Errorf("%v : the chunk %v isn't visible", position, chunk)
+			}
+		}
+		
+		// The next chunk on each axis is out of sight
+		for axis := 0 ; axis < 3 ; axis++ {
+			far := position
+			far[axis] += float64(2 * chunkSize)
+			if visible[ChunkOf(far)] || AreInVisibleChunks(position, far) {
+				t.Errorf("%v : the chunk of %v is visible", position, far)
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package throttle
+
+import (
+	"sync"
+	"time"
+	"glitchyverse/clock"
+)
+
+type Policy struct {
+	FreeAttempts    int           // Failures allowed before the backoff starts
+	BaseDelay       time.Duration // Delay after the first failure beyond the free ones, doubled at each failure
+	MaxDelay        time.Duration
+	LockoutAttempts int           // Failures leading to a lockout (0 = never)
+	LockoutDuration time.Duration
+	ResetAfter      time.Duration // Failures are forgotten after this delay without a new one
+}
+
+const maxEntriesBeforePruning = 10000
+
+// The pending attempts are forgotten after it, in case one is never ended (after a panic)
+const maxAttemptDuration = time.Minute
+
+type entry struct {
+	failures    int
+	pending     int // Reserved attempts not ended yet, which can be failures
+	reservedAt  time.Time
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// Counts the failures of keys (IPs, account names ...) and computes how long they must wait
+// before their next attempt, with an exponential backoff.
+// The attempts are reserved before being checked, so parallel attempts can't pass the backoff.
+type Limiter struct {
+	policy  Policy
+	clock   clock.Clock
+	mutex   sync.Mutex
+	entries map[string]*entry
+}
+
+func NewLimiter(policy Policy, clock clock.Clock) *Limiter {
+	return &Limiter{
+		policy:  policy,
+		clock:   clock,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Reserves an attempt, which must then be ended by Fail or Release. Returns how long the key
+// must wait before its next attempt, 0 if the attempt is reserved. Beyond the free attempts,
+// an attempt can't start before the previous one has ended.
+func (l *Limiter) Reserve(key string) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	now := l.clock.Now()
+	e := l.entry(key, now)
+	
+	if now.Before(e.lockedUntil) {
+		return e.lockedUntil.Sub(now)
+	}
+	
+	// The pending attempts are counted as failures until they end
+	if delay := l.delay(e.failures + e.pending); delay > 0 {
+		if e.pending > 0 {
+			return delay
+		}
+		if next := e.lastFailure.Add(delay); now.Before(next) {
+			return next.Sub(now)
+		}
+	}
+	
+	e.pending++
+	e.reservedAt = now
+	return 0
+}
+
+// Ends a reserved attempt which succeeded, without forgetting the failures
+func (l *Limiter) Release(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	if e, ok := l.entries[key]; ok && e.pending > 0 {
+		e.pending--
+		if e.pending == 0 && e.failures == 0 && e.lockedUntil.IsZero() {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// Ends a reserved attempt with a failure. Returns the end of the lockout if it leads to one (zero time otherwise).
+func (l *Limiter) Fail(key string) (lockedUntil time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	now := l.clock.Now()
+	e := l.entry(key, now)
+	
+	if e.pending > 0 {
+		e.pending--
+	}
+	e.failures++
+	e.lastFailure = now
+	
+	if l.policy.LockoutAttempts > 0 && e.failures >= l.policy.LockoutAttempts {
+		// The counter restarts after the lockout
+		e.failures = 0
+		e.lockedUntil = now.Add(l.policy.LockoutDuration)
+		return e.lockedUntil
+	}
+	
+	return time.Time{}
+}
+
+// Forgets the failures of the key
+func (l *Limiter) Reset(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	delete(l.entries, key)
+}
+
+// Returns the entry of the key, created if needed. The failures are forgotten once stale.
+func (l *Limiter) entry(key string, now time.Time) *entry {
+	e, ok := l.entries[key]
+	if !ok {
+		if len(l.entries) >= maxEntriesBeforePruning {
+			l.prune(now)
+		}
+		e = &entry{}
+		l.entries[key] = e
+	} else if l.isStale(e, now) && !now.Before(e.lockedUntil) {
+		e.failures = 0
+	}
+	if now.Sub(e.reservedAt) > maxAttemptDuration {
+		e.pending = 0
+	}
+	return e
+}
+
+// Delay after the given number of failures, without counting the time since the last one
+func (l *Limiter) delay(failures int) time.Duration {
+	if failures <= l.policy.FreeAttempts {
+		return 0
+	}
+	
+	delay := l.policy.BaseDelay
+	for i := l.policy.FreeAttempts + 1 ; i < failures && delay < l.policy.MaxDelay ; i++ {
+		delay *= 2
+	}
+	if delay > l.policy.MaxDelay {
+		delay = l.policy.MaxDelay
+	}
+	return delay
+}
+
+func (l *Limiter) isStale(e *entry, now time.Time) bool {
+	return now.Sub(e.lastFailure) > l.policy.ResetAfter
+}
+
+func (l *Limiter) prune(now time.Time) {
+	for key, e := range l.entries {
+		if (e.pending == 0 || now.Sub(e.reservedAt) > maxAttemptDuration) && l.isStale(e, now) && !now.Before(e.lockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package throttle
+
+import (
+	"testing"
+	"time"
+	"glitchyverse/clock"
+)
+
+var testPolicy = Policy{
+	FreeAttempts:    2,
+	BaseDelay:       time.Second,
+	MaxDelay:        5 * time.Second,
+	LockoutAttempts: 8,
+	LockoutDuration: time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+var testStart = time.Unix(1000000, 0)
+
+// Fails an attempt, which must be allowed
+func mustFail(t *testing.T, l *Limiter, key string) time.Time {
+	if wait := l.Reserve(key); wait != 0 {
+		t.Fatalf("attempt of %s refused for %v", key, wait)
+	}
+	return l.Fail(key)
+}
+
+func TestLimiterBackoff(t *testing.T) {
+	c := clock.NewManual(testStart)
+	l := NewLimiter(testPolicy, c)
+	
+	// Free attempts, then the delay doubles up to the maximum
+	for i, want := range []time.Duration{0, 0, 1, 2, 4, 5, 5} {
+		mustFail(t, l, "ann")
+		wait := l.Reserve("ann")
+		if wait != want * time.Second {
+			t.Fatalf("after %d failures : got %v, want %v", i + 1, wait, want * time.Second)
+		}
+		if wait == 0 {
+			l.Release("ann")
+		}
+		c.Advance(wait)
+	}
+	
+	// The other keys aren't affected
+	if wait := l.Reserve("bob"); wait != 0 {
+		t.Fatalf("bob : got %v", wait)
+	}
+}
+
+func TestLimiterLockout(t *testing.T) {
+	c := clock.NewManual(testStart)
+	l := NewLimiter(testPolicy, c)
+	
+	var lockedUntil time.Time
+	for i := 0 ; i < testPolicy.LockoutAttempts ; i++ {
+		lockedUntil = mustFail(t, l, "ann")
+		c.Advance(testPolicy.MaxDelay)
+	}
+	if want := c.Now().Add(testPolicy.LockoutDuration - testPolicy.MaxDelay); !lockedUntil.Equal(want) {
+		t.Fatalf("locked until %v, want %v", lockedUntil, want)
+	}
+	if wait := l.Reserve("ann"); wait != lockedUntil.Sub(c.Now()) {
+		t.Fatalf("during the lockout : got %v", wait)
+	}
+	
+	// The counter restarts after the lockout
+	c.Advance(lockedUntil.Sub(c.Now()))
+	for i := 0 ; i < testPolicy.FreeAttempts ; i++ {
+		mustFail(t, l, "ann")
+	}
+	if wait := l.Reserve("ann"); wait != 0 {
+		t.Fatalf("after the lockout : got %v", wait)
+	}
+}
+
+func TestLimiterReset(t *testing.T) {
+	c := clock.NewManual(testStart)
+	l := NewLimiter(testPolicy, c)
+	
+	for i := 0 ; i < 4 ; i++ {
+		mustFail(t, l, "ann")
+		c.Advance(testPolicy.MaxDelay)
+	}
+	l.Reset("ann")
+	if wait := l.Reserve("ann"); wait != 0 {
+		t.Fatalf("after the reset : got %v", wait)
+	}
+	
+	// The failures are also forgotten after ResetAfter
+	for i := 0 ; i < 4 ; i++ {
+		mustFail(t, l, "bob")
+		c.Advance(testPolicy.MaxDelay)
+	}
+	c.Advance(testPolicy.ResetAfter + time.Second)
+	if wait := l.Reserve("bob"); wait != 0 {
+		t.Fatalf("after ResetAfter : got %v", wait)
+	}
+}
+
+// Parallel attempts are counted as failures until they end
+func TestLimiterReservations(t *testing.T) {
+	c := clock.NewManual(testStart)
+	l := NewLimiter(testPolicy, c)
+	
+	// The free failures, then the first one delaying the next attempts
+	for i := 0 ; i <= testPolicy.FreeAttempts ; i++ {
+		if wait := l.Reserve("ann"); wait != 0 {
+			t.Fatalf("attempt %d refused for %v", i + 1, wait)
+		}
+	}
+	if wait := l.Reserve("ann"); wait != testPolicy.BaseDelay {
+		t.Fatalf("attempt beyond the free ones : got %v, want %v", wait, testPolicy.BaseDelay)
+	}
+	
+	// The successes end the reservations
+	l.Release("ann")
+	if wait := l.Reserve("ann"); wait != 0 {
+		t.Fatalf("after a success : got %v", wait)
+	}
+	
+	// A reservation which is never ended expires
+	c.Advance(maxAttemptDuration + time.Second)
+	for i := 0 ; i <= testPolicy.FreeAttempts ; i++ {
+		if wait := l.Reserve("ann"); wait != 0 {
+			t.Fatalf("after the expiry, attempt %d refused for %v", i + 1, wait)
+		}
+	}
+}
+
+func TestLimiterPruning(t *testing.T) {
+	c := clock.NewManual(testStart)
+	l := NewLimiter(testPolicy, c)
+	
+	mustFail(t, l, "stale")
+	l.Reserve("pending")
+	c.Advance(testPolicy.ResetAfter + time.Second)
+	mustFail(t, l, "recent")
+	l.Reserve("pending")
+	
+	l.prune(c.Now())
+	if _, ok := l.entries["stale"]; ok {
+		t.Errorf("stale entry kept")
+	}
+	for _, key := range []string{"recent", "pending"} {
+		if _, ok := l.entries[key]; !ok {
+			t.Errorf("entry %s pruned", key)
+		}
+	}
+}
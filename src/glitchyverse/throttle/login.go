@@ -0,0 +1,118 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package throttle
+
+import (
+	"time"
+	"glitchyverse/clock"
+)
+
+// Results of the login attempts written in the journal
+const (
+	ResultSuccess   = "success"
+	ResultFailure   = "failure"
+	ResultThrottled = "throttled" // Refused before checking the password
+)
+
+// Persistence of the account lockouts, and audit log of the attempts
+type Journal interface {
+	LockedUntil(account string) time.Time
+	Lock(account string, until time.Time)
+	Record(account string, ip string, result string, at time.Time)
+}
+
+var DefaultIpPolicy = Policy{
+	FreeAttempts:    5,
+	BaseDelay:       time.Second,
+	MaxDelay:        5 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+var DefaultAccountPolicy = Policy{
+	FreeAttempts:    3,
+	BaseDelay:       2 * time.Second,
+	MaxDelay:        10 * time.Minute,
+	LockoutAttempts: 10,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+// Throttles the login attempts by IP and by account
+type LoginGuard struct {
+	clock    clock.Clock
+	ips      *Limiter
+	accounts *Limiter
+	journal  Journal
+}
+
+func NewLoginGuard(ipPolicy Policy, accountPolicy Policy, journal Journal, clock clock.Clock) *LoginGuard {
+	return &LoginGuard{
+		clock:    clock,
+		ips:      NewLimiter(ipPolicy, clock),
+		accounts: NewLimiter(accountPolicy, clock),
+		journal:  journal,
+	}
+}
+
+// Must be called before checking the password. An allowed attempt is reserved until Failed or
+// Succeeded is called, so that parallel attempts are throttled as if it was a failure.
+// If the attempt isn't allowed, it's recorded as throttled, and the returned duration is how long
+// the user must wait.
+func (g *LoginGuard) Attempt(account string, ip string) (allowed bool, retryAfter time.Duration) {
+	now := g.clock.Now()
+	
+	if lockedUntil := g.journal.LockedUntil(account); now.Before(lockedUntil) {
+		retryAfter = lockedUntil.Sub(now)
+	} else if retryAfter = g.ips.Reserve(ip); retryAfter == 0 {
+		if retryAfter = g.accounts.Reserve(account); retryAfter > 0 {
+			g.ips.Release(ip)
+		}
+	}
+	
+	if retryAfter > 0 {
+		g.journal.Record(account, ip, ResultThrottled, now)
+		return false, retryAfter
+	}
+	return true, 0
+}
+
+// Ends an allowed attempt with a wrong password
+func (g *LoginGuard) Failed(account string, ip string) {
+	g.journal.Record(account, ip, ResultFailure, g.clock.Now())
+	
+	g.ips.Fail(ip)
+	if lockedUntil := g.accounts.Fail(account); !lockedUntil.IsZero() {
+		g.journal.Lock(account, lockedUntil)
+	}
+}
+
+// Only the failures of the account are forgotten : logging into its own account
+// must not let an IP guess the passwords of the other ones.
+func (g *LoginGuard) Succeeded(account string, ip string) {
+	g.journal.Record(account, ip, ResultSuccess, g.clock.Now())
+	
+	g.ips.Release(ip)
+	g.accounts.Reset(account)
+}
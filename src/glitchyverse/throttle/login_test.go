@@ -0,0 +1,135 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package throttle
+
+import (
+	"testing"
+	"time"
+	"glitchyverse/clock"
+)
+
+type attemptRecord struct {
+	account string
+	ip      string
+	result  string
+}
+
+type fakeJournal struct {
+	locks   map[string]time.Time
+	records []attemptRecord
+}
+
+func newFakeJournal() *fakeJournal {
+	return &fakeJournal{locks: make(map[string]time.Time)}
+}
+
+func (j *fakeJournal) LockedUntil(account string) time.Time {
+	return j.locks[account]
+}
+
+func (j *fakeJournal) Lock(account string, until time.Time) {
+	j.locks[account] = until
+}
+
+func (j *fakeJournal) Record(account string, ip string, result string, at time.Time) {
+	j.records = append(j.records, attemptRecord{account, ip, result})
+}
+
+func TestLoginGuardParallelAttempts(t *testing.T) {
+	c := clock.NewManual(testStart)
+	journal := newFakeJournal()
+	g := NewLoginGuard(DefaultIpPolicy, testPolicy, journal, c)
+	
+	// The passwords of the allowed attempts are being checked
+	for i := 0 ; i <= testPolicy.FreeAttempts ; i++ {
+		if allowed, _ := g.Attempt("ann", "10.0.0.1"); !allowed {
+			t.Fatalf("attempt %d refused", i + 1)
+		}
+	}
+	if allowed, _ := g.Attempt("ann", "10.0.0.2"); allowed {
+		t.Fatalf("parallel attempt beyond the free ones allowed")
+	}
+	if last := journal.records[len(journal.records) - 1]; last.result != ResultThrottled {
+		t.Fatalf("refused attempt recorded as %s", last.result)
+	}
+	
+	// The other accounts can still be tried from this IP
+	if allowed, _ := g.Attempt("bob", "10.0.0.1"); !allowed {
+		t.Fatalf("attempt on another account refused")
+	}
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	c := clock.NewManual(testStart)
+	journal := newFakeJournal()
+	g := NewLoginGuard(DefaultIpPolicy, testPolicy, journal, c)
+	
+	for i := 0 ; i < testPolicy.LockoutAttempts ; i++ {
+		// From many IPs, so that only the account is throttled
+		ip := string(rune('a' + i))
+		if allowed, retryAfter := g.Attempt("ann", ip); !allowed {
+			t.Fatalf("attempt %d refused for %v", i + 1, retryAfter)
+		}
+		g.Failed("ann", ip)
+		c.Advance(testPolicy.MaxDelay)
+	}
+	
+	until, ok := journal.locks["ann"]
+	if !ok {
+		t.Fatalf("account not locked")
+	}
+	if allowed, retryAfter := g.Attempt("ann", "z"); allowed || retryAfter != until.Sub(c.Now()) {
+		t.Fatalf("during the lockout : got %v, %v", allowed, retryAfter)
+	}
+	
+	c.Advance(until.Sub(c.Now()))
+	if allowed, _ := g.Attempt("ann", "z"); !allowed {
+		t.Fatalf("attempt refused after the lockout")
+	}
+}
+
+func TestLoginGuardSuccess(t *testing.T) {
+	c := clock.NewManual(testStart)
+	journal := newFakeJournal()
+	ipPolicy := testPolicy
+	ipPolicy.LockoutAttempts = 0
+	g := NewLoginGuard(ipPolicy, testPolicy, journal, c)
+	
+	for i := 0 ; i < 3 ; i++ {
+		g.Attempt("ann", "10.0.0.1")
+		g.Failed("ann", "10.0.0.1")
+		c.Advance(testPolicy.MaxDelay)
+	}
+	g.Attempt("ann", "10.0.0.1")
+	g.Succeeded("ann", "10.0.0.1")
+	
+	// The failures of the account are forgotten, not the ones of the IP
+	if _, ok := g.accounts.entries["ann"]; ok {
+		t.Errorf("failures of the account kept after a success")
+	}
+	if e, ok := g.ips.entries["10.0.0.1"]; !ok || e.failures != 3 || e.pending != 0 {
+		t.Errorf("failures of the IP : got %+v", e)
+	}
+}
@@ -0,0 +1,186 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"unicode/utf8"
+	"github.com/gorilla/websocket"
+	"glitchyverse/model"
+	"glitchyverse/database"
+	"glitchyverse/protocol"
+)
+
+const (
+	userNameMinLength,      userNameMaxLength      = 3, 32
+	passwordMinLength,      passwordMaxLength      = 8, 72 // bcrypt ignores what is after 72 bytes
+	spaceShipNameMinLength, spaceShipNameMaxLength = 3, 64
+)
+
+type starterBuilding struct {
+	model    string
+	position model.Vec3
+	size     model.Vec3
+}
+
+// Buildings of new spaceships. The character is named after the user.
+var starterLayout = []starterBuilding{
+	{"Room",      model.Vec3{-1, 0, -1}, model.Vec3{3, 1, 3}},
+	{"Console",   model.Vec3{ 0, 0, -1}, model.Vec3{1, 1, 1}},
+	{"Character", model.Vec3{ 0, 0,  0}, model.Vec3{1, 1, 1}},
+}
+
+// Creates a user with its spaceship, then connects it
+func (user *User) Register(name, password, spaceShipName string) error {
+	if user.UserId > 0 {
+		return protocol.Refused("Already connected")
+	}
+	
+	name = strings.TrimSpace(name)
+	spaceShipName = strings.TrimSpace(spaceShipName)
+	if err := checkLength("Name", name, userNameMinLength, userNameMaxLength); err != nil {
+		return err
+	}
+	if err := checkPasswordLength(password); err != nil {
+		return err
+	}
+	if err := checkLength("Spaceship name", spaceShipName, spaceShipNameMinLength, spaceShipNameMaxLength); err != nil {
+		return err
+	}
+	
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return protocol.Invalid(err.Error())
+	}
+	
+	var refusal error
+	db.DeferredTransaction(func(tx db.Store) bool {
+		inserted, userId := tx.InsertUser(name, passwordHash)
+		if !inserted {
+			refusal = protocol.Refused("This name is already used")
+			return false
+		}
+		
+		inserted, spaceShipId := tx.InsertSpaceShip(userId, spaceShipName)
+		if !inserted {
+			refusal = protocol.Refused("This spaceship name is already used")
+			return false
+		}
+		
+		for _, b := range starterLayout {
+			seed := ""
+			if b.model == "Character" {
+				seed = name
+			}
+			tx.InsertStarterBuilding(spaceShipId, b.model, b.position, b.size, seed)
+		}
+		
+		return true
+	})
+	if refusal != nil {
+		return refusal
+	}
+	
+	user.Connect(name, password)
+	return nil
+}
+
+func (user *User) ChangePassword(oldPassword, newPassword string) error {
+	if err := user.checkCurrentPassword(oldPassword); err != nil {
+		return err
+	}
+	if err := checkPasswordLength(newPassword); err != nil {
+		return err
+	}
+	
+	passwordHash, err := hashPassword(newPassword)
+	if err != nil {
+		return protocol.Invalid(err.Error())
+	}
+	
+	db.SetUserPassword(user.UserId, passwordHash)
+	
+	// The other places where the account was connected must use the new password
+	db.RevokeSessions(user.UserId)
+	user.issueSessionToken()
+	
+	return nil
+}
+
+// Deletes the user, its spaceship and everything in it. The user is disconnected after the reply.
+func (user *User) DeleteAccount(password string) error {
+	if err := user.checkCurrentPassword(password); err != nil {
+		return err
+	}
+	
+	db.DeferredTransaction(func(tx db.Store) bool {
+		tx.DeleteUser(user.UserId)
+		return true
+	})
+	log.Println("Account deleted :", user.UserName)
+	
+	user.CloseAfterReply(websocket.CloseNormalClosure, "accountDeleted")
+	return nil
+}
+
+// Throttled like the logins, so that a stolen session can't be used to guess the password
+func (user *User) checkCurrentPassword(password string) error {
+	ip := user.RemoteIp()
+	if allowed, retryAfter := Logins.Attempt(user.UserName, ip); !allowed {
+		return protocol.Refused(fmt.Sprintf(
+			"Too many failed attempts, please retry in %d seconds.",
+			int64(math.Ceil(retryAfter.Seconds())),
+		))
+	}
+	
+	userId, passwordHash := db.GetUserCredentials(user.UserName)
+	if userId != user.UserId {
+		Logins.Failed(user.UserName, ip)
+		return protocol.Refused("Unknown user")
+	}
+	if valid, _ := checkPassword(user.UserName, password, passwordHash); !valid {
+		Logins.Failed(user.UserName, ip)
+		return protocol.Refused("Wrong password")
+	}
+	Logins.Succeeded(user.UserName, ip)
+	return nil
+}
+
+func checkPasswordLength(password string) error {
+	if len(password) < passwordMinLength || len(password) > passwordMaxLength {
+		return protocol.Invalid(fmt.Sprintf("The password must contain between %d and %d bytes", passwordMinLength, passwordMaxLength))
+	}
+	return nil
+}
+
+func checkLength(field string, value string, min int, max int) error {
+	if length := utf8.RuneCountInString(value); length < min || length > max {
+		return protocol.Invalid(field + " length is not valid")
+	}
+	return nil
+}
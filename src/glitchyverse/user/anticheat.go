@@ -0,0 +1,145 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"math"
+	"time"
+	"github.com/gorilla/websocket"
+	"glitchyverse/anticheat"
+	"glitchyverse/database"
+	"glitchyverse/flight"
+	"glitchyverse/model"
+)
+
+// Scores the movement violations of the users, see ConfigureAntiCheat
+var Movements = anticheat.NewValidator(anticheat.DefaultPolicy, databaseIncidentJournal{})
+
+func ConfigureAntiCheat(policy anticheat.Policy) {
+	Movements = anticheat.NewValidator(policy, databaseIncidentJournal{})
+}
+
+// Writes the movement incidents into the database
+type databaseIncidentJournal struct{}
+
+func (databaseIncidentJournal) Record(userId int64, kind string, score float64, response string, at time.Time) {
+	db.InsertMovementIncident(userId, kind, score, response, at.Unix())
+}
+
+// Shortest time between two measured reports of a client, so that the latency variations stay small beside it
+const measureInterval = time.Second
+
+// Movement predicted by a client
+type movementReport struct {
+	position      model.Vec3
+	rotation      model.Quat
+	velocity      model.Vec3 // Since the previous measured report
+	angularSpeed  float64    // Degrees per second
+	hasVelocities bool       // False for the first report
+	at            time.Time
+}
+
+// The movement of the server, from which the client starts again after a correction
+func serverMovementReport(motion flight.Motion, at time.Time) movementReport {
+	return movementReport{
+		position:      motion.Position,
+		rotation:      motion.Rotation,
+		velocity:      motion.Velocity,
+		angularSpeed:  motion.AngularVelocity.Length(),
+		hasVelocities: true,
+		at:            at,
+	}
+}
+
+// Measures the velocities of the next report since this one. Returns false if it's too early to measure
+// them : the next report must not replace this one.
+func (previous movementReport) measure(position model.Vec3, rotation model.Quat, at time.Time) (report movementReport, ok bool) {
+	report = movementReport{position: position, rotation: rotation, at: at}
+	if previous.at.IsZero() {
+		return report, true
+	}
+	
+	seconds := at.Sub(previous.at).Seconds()
+	if seconds < measureInterval.Seconds() {
+		return report, false
+	}
+	report.velocity = position.Sub(previous.position).Scale(1 / seconds)
+	report.angularSpeed = rotation.AngleTo(previous.rotation) / seconds
+	report.hasVelocities = true
+	return report, true
+}
+
+// Returns the kind of violation of a predicted movement, or "" if the propellers of the spaceship can do it :
+// the velocities can't change faster than the accelerations given by the propellers.
+func movementViolation(previous, report movementReport, motion flight.Motion, ship flight.Ship) string {
+	if report.position.Sub(motion.Position).Length() > TeleportDistance {
+		return anticheat.KindTeleport
+	}
+	if !previous.hasVelocities || !report.hasVelocities {
+		return ""
+	}
+	
+	seconds := report.at.Sub(previous.at).Seconds()
+	switch {
+		case report.velocity.Sub(previous.velocity).Length() / seconds > ship.Acceleration() + AccelerationTolerance:
+			return anticheat.KindAcceleration
+		case math.Abs(report.angularSpeed - previous.angularSpeed) / seconds > ship.AngularAcceleration() + AngularAccelerationTolerance:
+			return anticheat.KindRotation
+	}
+	return ""
+}
+
+// True if the predicted movement is close enough to the one of the server to be kept by the client
+func isPredictionClose(position model.Vec3, rotation model.Quat, motion flight.Motion) bool {
+	return position.Sub(motion.Position).Length() <= PositionTolerance && rotation.AngleTo(motion.Rotation) <= RotationTolerance
+}
+
+// Stops the spaceship and its propellers. Returns its new movement.
+func (user *User) freeze() (motion flight.Motion) {
+	db.DeferredTransaction(func(tx db.Store) bool {
+		motion, _ = spaceShipMotion(tx, user.SpaceShipId)
+		motion.Velocity, motion.AngularVelocity = model.Vec3{}, model.Vec3{}
+		setSpaceShipMotion(tx, user.SpaceShipId, motion)
+		
+		tx.SetPropellersPowerRate(user.SpaceShipId, 0, 0)
+		return true
+	})
+	
+	user.SendMessageBroadcast("updatePropellers", struct{
+		SpaceshipId int64   `json:"spaceshipId"`
+		Id          *int64  `json:"id"` // Every propeller
+		Power       float64 `json:"power"`
+	}{user.SpaceShipId, nil, 0}, false)
+	
+	return motion
+}
+
+// Disconnects the user once the current request is answered
+func (user *User) kick() {
+	user.SendMessage("kicked", struct{
+		Message string `json:"message"`
+	}{"You have been disconnected because of impossible movements."})
+	user.CloseAfterReply(websocket.ClosePolicyViolation, "kicked")
+}
@@ -0,0 +1,220 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"log"
+	"sync"
+	"strconv"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"glitchyverse/database"
+	"glitchyverse/model"
+	"glitchyverse/protocol"
+)
+
+// Type definitions (building types, item groups and item types) sent to the clients.
+// They are built only once, and the clients which already have them (same hash) don't receive them.
+type definitions struct {
+	hash          string
+	messages      []definitionMessage
+	buildingTypes map[int64]model.BuildingType
+	
+	mutex  sync.Mutex
+	frames map[int][][]byte // Encoded messages by protocol version
+}
+
+type definitionMessage struct {
+	method string
+	data   interface{}
+}
+
+var (
+	definitionsMutex   sync.RWMutex
+	currentDefinitions *definitions
+	loadingDefinitions sync.Mutex // Held while loading, so that they are loaded once at a time
+)
+
+// Loads the definitions from the database. They are loaded on first use otherwise.
+// Must be called again after the definitions of the database are modified (the server
+// does it on SIGHUP, after a content sync) : the clients connecting after it get the new ones.
+func LoadDefinitions() {
+	loadingDefinitions.Lock()
+	defer loadingDefinitions.Unlock()
+	loadDefinitions()
+}
+
+// Must be called with loadingDefinitions locked
+func loadDefinitions() {
+	buildingTypes := buildingTypesDefinition()
+	d := &definitions{
+		messages: []definitionMessage{
+			{"data_buildingTypesDefinition", buildingTypes         },
+			{"data_itemGroupsDefinition",    itemGroupsDefinition()},
+			{"data_itemTypesDefinition",     itemTypesDefinition() },
+		},
+		buildingTypes: make(map[int64]model.BuildingType),
+		frames:        make(map[int][][]byte),
+	}
+	for _, t := range buildingTypes {
+		d.buildingTypes[t.Id] = t
+	}
+	
+	// The hash identifies the content, the JSON encoding of maps being sorted by key
+	h := sha256.New()
+	for _, m := range d.messages {
+		data, err := json.Marshal(m.data)
+		if err != nil {
+			log.Panic(err)
+		}
+		h.Write([]byte(m.method + "#"))
+		h.Write(data)
+	}
+	d.hash = hex.EncodeToString(h.Sum(nil))
+	
+	definitionsMutex.Lock()
+	currentDefinitions = d
+	definitionsMutex.Unlock()
+}
+
+func loadedDefinitions() *definitions {
+	definitionsMutex.RLock()
+	defer definitionsMutex.RUnlock()
+	return currentDefinitions
+}
+
+// Loads the definitions on first use, which must not be in a transaction : the package functions would wait for it.
+// The concurrent first uses wait for a single load.
+func getDefinitions() *definitions {
+	if d := loadedDefinitions(); d != nil {
+		return d
+	}
+	
+	loadingDefinitions.Lock()
+	defer loadingDefinitions.Unlock()
+	if loadedDefinitions() == nil {
+		loadDefinitions()
+	}
+	return loadedDefinitions()
+}
+
+// Hash of the current definitions
+func DefinitionsHash() string {
+	return getDefinitions().hash
+}
+
+// Returns the messages encoded for a protocol version, encoding them on first use
+func (d *definitions) encoded(protocolVersion int) [][]byte {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	
+	frames, ok := d.frames[protocolVersion]
+	if !ok {
+		for _, m := range d.messages {
+			frame, err := protocol.Encode(protocolVersion, 0, m.method, m.data)
+			if err != nil {
+				log.Panic(err)
+			}
+			frames = append(frames, frame)
+		}
+		d.frames[protocolVersion] = frames
+	}
+	return frames
+}
+
+// Sends the definitions, or only tells the client that its cached definitions are up to date.
+// Legacy clients don't cache them.
+func (user *User) sendDefinitions() {
+	d := getDefinitions()
+	hash := struct{
+		Hash string `json:"hash"`
+	}{d.hash}
+	
+	if user.Protocol != protocol.VersionLegacy && user.ClientDefinitionsHash == d.hash {
+		user.SendMessage("definitionsUpToDate", hash)
+		return
+	}
+	
+	for _, frame := range d.encoded(user.Protocol) {
+		user.outbound.push(frame, "")
+	}
+	if user.Protocol != protocol.VersionLegacy {
+		user.SendMessage("definitionsHash", hash)
+	}
+}
+
+func itemGroupsDefinition() interface{} {
+	definition := make(map[string]string)
+	db.GetItemGroups(func(id int64, name string) {
+		definition[strconv.FormatInt(id, 10)] = name
+	})
+	return definition
+}
+
+func itemTypesDefinition() interface{} {
+	itemGroups := make(map[string][]int64)
+	db.GetItemTypesInItemGroups(func(typeId, groupId int64) {
+		groups, ok := itemGroups[strconv.FormatInt(typeId, 10)]
+		if !ok {
+			groups = make([]int64, 0)
+		}
+		itemGroups[strconv.FormatInt(typeId, 10)] = append(groups, groupId)
+	})
+	
+	definition := make([]interface{}, 0)
+	db.GetItemTypes(func(id int64, name string, maxState float64) {
+		groups := itemGroups[strconv.FormatInt(id, 10)]
+		if groups == nil {
+			groups = make([]int64, 0)
+		}
+		
+		definition = append(definition, struct{
+			Id       int64   `json:"id"`
+			Name     string  `json:"name"`
+			MaxState float64 `json:"maxState"`
+			Groups   []int64 `json:"groups"`
+		}{id, name, maxState, groups})
+	})
+	
+	return definition
+}
+
+func buildingTypesDefinition() []model.BuildingType {
+	itemSlots := make(map[int64][]model.ItemSlot)
+	for _, slot := range db.GetItemSlots() {
+		itemSlots[slot.BuildingTypeId] = append(itemSlots[slot.BuildingTypeId], slot)
+	}
+	
+	definition := db.GetBuildingTypes()
+	for i := range definition {
+		definition[i].Slots = itemSlots[definition[i].Id]
+		if definition[i].Slots == nil {
+			definition[i].Slots = make([]model.ItemSlot, 0)
+		}
+	}
+	
+	return definition
+}
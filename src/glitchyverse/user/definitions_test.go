@@ -0,0 +1,64 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"sync"
+	"testing"
+)
+
+// The concurrent first uses share the same load
+func TestGetDefinitionsLoadsOnce(t *testing.T) {
+	useMemoryStore(t)
+	definitionsMutex.Lock()
+	currentDefinitions = nil
+	definitionsMutex.Unlock()
+	
+	loaded := make([]*definitions, 8)
+	var wg sync.WaitGroup
+	for i := range loaded {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			loaded[i] = getDefinitions()
+		}(i)
+	}
+	wg.Wait()
+	
+	for _, d := range loaded[1:] {
+		if d != loaded[0] {
+			t.Fatal("The definitions have been loaded more than once")
+		}
+	}
+	if len(loaded[0].buildingTypes) == 0 {
+		t.Error("No building type loaded")
+	}
+	
+	// A reload replaces them
+	LoadDefinitions()
+	if getDefinitions() == loaded[0] {
+		t.Error("The definitions have not been reloaded")
+	}
+}
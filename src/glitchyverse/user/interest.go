@@ -0,0 +1,142 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"sort"
+	"sync"
+	"glitchyverse/database"
+	"glitchyverse/model"
+	"glitchyverse/space"
+)
+
+// Guards the spaceships known by the clients of the users. The messages about a spaceship are sent
+// while holding it, so that they can't arrive before its data_spaceship or after its deleteSpaceship.
+// The database must not be used while holding it : a transaction can be waiting for it.
+var interests sync.RWMutex
+
+// Positions of the spaceships of the users
+func spaceShipPositions(store db.Store, users []*User) map[*User]model.Vec3 {
+	positions := make(map[*User]model.Vec3, len(users))
+	for _, u := range users {
+		if spaceShip, found := store.GetSpaceShip(u.SpaceShipId); found {
+			positions[u] = spaceShip.Position
+		}
+	}
+	return positions
+}
+
+// Updates the interests of the online users, from the positions of their spaceships in the database
+func updateAllInterests() {
+	var positions map[*User]model.Vec3
+	db.DeferredTransaction(func(tx db.Store) bool {
+		positions = spaceShipPositions(tx, Users.Snapshot(true))
+		return true
+	})
+	updateInterests(positions)
+}
+
+// Sends to each user the spaceships of the other users which entered its visible chunks (data_spaceship),
+// and the ones which left them (deleteSpaceship). The users are grouped by chunk, so that only the users
+// in the chunks visible from each one are compared.
+func updateInterests(positions map[*User]model.Vec3) {
+	byChunk := make(map[[3]int64][]*User)
+	updated := make(map[int64]bool) // Spaceships of the users
+	for u, position := range positions {
+		chunk := space.ChunkOf(position)
+		byChunk[chunk] = append(byChunk[chunk], u)
+		updated[u.SpaceShipId] = true
+	}
+	
+	// Spaceships entering the range of each user
+	entering := make(map[*User][]int64)
+	
+	interests.Lock()
+	for u, position := range positions {
+		visible := make(map[int64]bool)
+		for _, chunk := range space.VisibleChunks(position) {
+			for _, other := range byChunk[chunk] {
+				if other.UserId == u.UserId {
+					continue
+				}
+				visible[other.SpaceShipId] = true
+				if !u.knownSpaceShips[other.SpaceShipId] {
+					entering[u] = append(entering[u], other.SpaceShipId)
+				}
+			}
+		}
+		
+		for spaceShipId := range u.knownSpaceShips {
+			if updated[spaceShipId] && !visible[spaceShipId] {
+				delete(u.knownSpaceShips, spaceShipId)
+				u.SendMessage("deleteSpaceship", spaceShipId)
+			}
+		}
+	}
+	interests.Unlock()
+	
+	if len(entering) == 0 {
+		return
+	}
+	
+	// Each spaceship entering the range of someone is loaded once
+	messages := make(map[int64]spaceShipMessage)
+	for _, spaceShipIds := range entering {
+		for _, spaceShipId := range spaceShipIds {
+			if _, ok := messages[spaceShipId]; !ok {
+				messages[spaceShipId] = newSpaceShipMessage(spaceShipId, false)
+			}
+		}
+	}
+	
+	// Meanwhile, the spaceship may have been sent by another update, or its owner may have left
+	// (then leaveInterests is either done, or waiting to delete it)
+	interests.Lock()
+	defer interests.Unlock()
+	for u, spaceShipIds := range entering {
+		sort.Slice(spaceShipIds, func(i, j int) bool {
+			return spaceShipIds[i] < spaceShipIds[j]
+		})
+		for _, spaceShipId := range spaceShipIds {
+			if !u.knownSpaceShips[spaceShipId] && Users.BySpaceShipId(spaceShipId) != nil {
+				u.knownSpaceShips[spaceShipId] = true
+				u.SendMessage("data_spaceship", messages[spaceShipId])
+			}
+		}
+	}
+}
+
+// Deletes the spaceship of a disconnected user from the clients which know it
+func (user *User) leaveInterests() {
+	interests.Lock()
+	defer interests.Unlock()
+	
+	for _, k := range Users.Snapshot(true) {
+		if k.knownSpaceShips[user.SpaceShipId] {
+			delete(k.knownSpaceShips, user.SpaceShipId)
+			k.SendMessage("deleteSpaceship", user.SpaceShipId)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"net"
+	"time"
+	"glitchyverse/clock"
+	"glitchyverse/database"
+	"glitchyverse/throttle"
+)
+
+// Throttles the password checks
+var Logins = throttle.NewLoginGuard(throttle.DefaultIpPolicy, throttle.DefaultAccountPolicy, databaseLoginJournal{}, clock.Real)
+
+// Writes the login journal into the database
+type databaseLoginJournal struct{}
+
+func (databaseLoginJournal) LockedUntil(account string) time.Time {
+	if until := db.GetLoginLockout(account); until > 0 {
+		return time.Unix(until, 0)
+	}
+	return time.Time{}
+}
+
+func (databaseLoginJournal) Lock(account string, until time.Time) {
+	db.SetLoginLockout(account, until.Unix())
+}
+
+func (databaseLoginJournal) Record(account string, ip string, result string, at time.Time) {
+	db.InsertLoginAttempt(account, ip, result, at.Unix())
+}
+
+// Returns the IP address of the user, without the port
+func (user *User) RemoteIp() string {
+	address := user.Socket.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
@@ -0,0 +1,136 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"glitchyverse/database"
+	"glitchyverse/flight"
+	"glitchyverse/model"
+)
+
+// Message sent to the clients, with the position computed by the server
+type positionMessage struct {
+	SpaceshipId     int64      `json:"spaceshipId"`
+	Position        model.Vec3 `json:"position"`
+	Rotation        model.Quat `json:"rotation"`
+	Velocity        model.Vec3 `json:"velocity"`
+	AngularVelocity model.Vec3 `json:"angularVelocity"`
+	Sequence        int64      `json:"sequence,omitempty"`   // Message of the owner which is corrected
+	Correction      bool       `json:"correction,omitempty"` // Only sent to the owner
+}
+
+func newPositionMessage(spaceShipId int64, motion flight.Motion, sequence int64, correction bool) positionMessage {
+	return positionMessage{
+		SpaceshipId:     spaceShipId,
+		Position:        motion.Position,
+		Rotation:        motion.Rotation,
+		Velocity:        motion.Velocity,
+		AngularVelocity: motion.AngularVelocity,
+		Sequence:        sequence,
+		Correction:      correction,
+	}
+}
+
+// Mass and thrust of a spaceship, from its buildings, items and propellers
+func spaceShipPhysics(store db.Store, spaceShipId int64) flight.Ship {
+	buildingTypes := getDefinitions().buildingTypes
+	
+	itemsMass := make(map[int64]float64)
+	for _, item := range store.GetItems(spaceShipId) {
+		itemsMass[item.BuildingId] += ItemMass
+	}
+	
+	buildings := store.GetBuildings(spaceShipId, -1)
+	parts := make([]flight.Part, 0, len(buildings))
+	propellers := make([]flight.Propeller, 0)
+	for _, b := range buildings {
+		parts = append(parts, flight.Part{
+			Position: b.Position,
+			Size:     b.Size,
+			Mass:     b.Size[0] * b.Size[1] * b.Size[2] * BuildingUnitMass + itemsMass[b.Id],
+		})
+		
+		if t := buildingTypes[b.TypeId]; t.CanExertThrust && b.IsBuilt && b.IsEnabled {
+			propellers = append(propellers, flight.Propeller{
+				Position: b.Position,
+				Size:     b.Size,
+				Rotation: b.Rotation,
+				State:    b.State,
+				MaxState: t.MaxState,
+			})
+		}
+	}
+	
+	return flight.NewShip(parts, propellers, SpaceShipThrustPerPropellerUnit, SpaceShipMaxSpeedPerPropellerUnit)
+}
+
+// Movement of the spaceship at its last simulation
+func spaceShipMotion(store db.Store, spaceShipId int64) (motion flight.Motion, found bool) {
+	spaceShip, found := store.GetSpaceShip(spaceShipId)
+	return flight.Motion{
+		Position:        spaceShip.Position,
+		Rotation:        spaceShip.Rotation,
+		Velocity:        spaceShip.Velocity,
+		AngularVelocity: spaceShip.AngularVelocity,
+	}, found
+}
+
+// Movement of an online spaceship now, extrapolated from its last simulation
+func currentSpaceShipMotion(store db.Store, spaceShipId int64) (motion flight.Motion, found bool) {
+	motion, found = spaceShipMotion(store, spaceShipId)
+	if found {
+		seconds := simulationTime(SimulationClock.Now()) - store.GetSpaceShipSimulatedAt(spaceShipId)
+		if seconds > 0 {
+			motion.Accelerate(spaceShipPhysics(store, spaceShipId), seconds)
+		}
+	}
+	return
+}
+
+func setSpaceShipMotion(store db.Store, spaceShipId int64, motion flight.Motion) {
+	store.SetSpaceShipMotion(spaceShipId, motion.Position, motion.Rotation, motion.Velocity, motion.AngularVelocity)
+}
+
+// Moves an online spaceship, pushed by its propellers
+func moveSpaceShip(store db.Store, spaceShipId int64, seconds float64) {
+	motion, found := spaceShipMotion(store, spaceShipId)
+	if !found || seconds <= 0 {
+		return
+	}
+	
+	motion.Accelerate(spaceShipPhysics(store, spaceShipId), seconds)
+	setSpaceShipMotion(store, spaceShipId, motion)
+}
+
+// Moves an offline spaceship, which keeps its velocities
+func driftSpaceShip(store db.Store, spaceShipId int64, seconds float64) {
+	motion, found := spaceShipMotion(store, spaceShipId)
+	if !found || seconds <= 0 {
+		return
+	}
+	
+	motion.Drift(seconds)
+	setSpaceShipMotion(store, spaceShipId, motion)
+}
@@ -0,0 +1,178 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"log"
+	"sync"
+	"time"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	outboundQueueSize = 256              // Frames waiting to be written before the user is considered as too slow
+	writeTimeout      = 10 * time.Second // A stalled client can't block its writer longer than this
+	closeTimeout      = time.Second
+)
+
+type outboundFrame struct {
+	data        []byte
+	coalesceKey string // Frames with the same key replace each other while they are waiting ("" = never coalesced)
+}
+
+// Queue of the frames to send to a user, written on the socket by a dedicated goroutine,
+// because the socket doesn't support concurrent writers.
+type outboundQueue struct {
+	socket  *websocket.Conn
+	frames  chan *outboundFrame
+	
+	mutex   sync.Mutex
+	pending map[string]*outboundFrame // Coalescable frames not written yet, by key
+	closed  bool
+	closeMessage []byte // Close control frame to send after the last frame (nil = none)
+	
+	closeOnce sync.Once
+	closing   chan struct{}
+	done      chan struct{}
+}
+
+func newOutboundQueue(socket *websocket.Conn) *outboundQueue {
+	q := &outboundQueue{
+		socket:  socket,
+		frames:  make(chan *outboundFrame, outboundQueueSize),
+		pending: make(map[string]*outboundFrame),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go q.writeLoop()
+	return q
+}
+
+// Adds a frame to the queue, without ever blocking.
+// When the queue is full, coalescable frames are dropped, and otherwise the socket is
+// closed (the reader loop then disconnects the user). Returns false if the frame is lost.
+func (q *outboundQueue) push(data []byte, coalesceKey string) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	
+	if q.closed {
+		return false
+	}
+	
+	if coalesceKey != "" {
+		// Replacing the frame waiting with the same key, it keeps its place in the queue
+		if frame, ok := q.pending[coalesceKey]; ok {
+			frame.data = data
+			return true
+		}
+	}
+	
+	frame := &outboundFrame{data, coalesceKey}
+	select {
+		case q.frames <- frame:
+			if coalesceKey != "" {
+				q.pending[coalesceKey] = frame
+			}
+			return true
+		default:
+			if coalesceKey == "" {
+				log.Println("Slow consumer disconnected :", q.socket.RemoteAddr())
+				q.closed = true
+				q.socket.Close()
+			}
+			return false
+	}
+}
+
+func (q *outboundQueue) setCloseMessage(closeMessage []byte) {
+	q.mutex.Lock()
+	q.closeMessage = closeMessage
+	q.mutex.Unlock()
+}
+
+// Stops accepting frames, waits until the waiting ones are written and stops the writer.
+func (q *outboundQueue) close() {
+	q.mutex.Lock()
+	q.closed = true
+	q.mutex.Unlock()
+	
+	q.closeOnce.Do(func() {
+		close(q.closing)
+	})
+	<-q.done
+}
+
+func (q *outboundQueue) writeLoop() {
+	defer close(q.done)
+	
+	failed := false
+	for {
+		select {
+			case frame := <-q.frames:
+				failed = q.write(frame, failed)
+			case <-q.closing:
+				// Flushing what is left
+				for {
+					select {
+						case frame := <-q.frames:
+							failed = q.write(frame, failed)
+						default:
+							q.mutex.Lock()
+							closeMessage := q.closeMessage
+							q.mutex.Unlock()
+							
+							if closeMessage != nil && !failed {
+								q.socket.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(closeTimeout))
+							}
+							return
+					}
+				}
+		}
+	}
+}
+
+// Writes a frame on the socket. Once a write has failed, the next frames are dropped.
+func (q *outboundQueue) write(frame *outboundFrame, failed bool) bool {
+	q.mutex.Lock()
+	data := frame.data
+	if frame.coalesceKey != "" {
+		delete(q.pending, frame.coalesceKey)
+	}
+	q.mutex.Unlock()
+	
+	if failed {
+		return true
+	}
+	
+	q.socket.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := q.socket.WriteMessage(websocket.TextMessage, data); err != nil {
+		// The reader loop will fail too and disconnect the user
+		log.Println(err)
+		q.socket.Close()
+		return true
+	}
+	
+	return false
+}
@@ -0,0 +1,68 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt generates a random salt for each hash, and stores it inside
+// the hash, so user_password contains everything required to check it.
+const passwordHashCost = bcrypt.DefaultCost
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordHashCost)
+	return string(hash), err
+}
+
+// Checks a password against the hash stored in the user table. needsRehash is true when the
+// hash must be replaced after a successful login : it's either a legacy unsalted
+// sha1(name + password) hash, or a bcrypt hash with an outdated cost.
+func checkPassword(name, password, storedHash string) (valid bool, needsRehash bool) {
+	if isLegacyPasswordHash(storedHash) {
+		encodedPass := sha1.Sum([]byte(name + password))
+		hashPass := hex.EncodeToString(encodedPass[:])
+		return subtle.ConstantTimeCompare([]byte(hashPass), []byte(storedHash)) == 1, true
+	}
+	
+	if bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(password)) != nil {
+		return false, false
+	}
+	
+	cost, err := bcrypt.Cost([]byte(storedHash))
+	return true, (err != nil || cost < passwordHashCost)
+}
+
+// Legacy hashes are hexadecimal sha1 sums, bcrypt ones start with "$2"
+func isLegacyPasswordHash(hash string) bool {
+	if len(hash) != 2 * sha1.Size {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
@@ -0,0 +1,156 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"math"
+	"time"
+	"glitchyverse/clock"
+	"glitchyverse/database"
+	"glitchyverse/model"
+)
+
+// Clock of the simulation (item production and movements), replaced by a manual clock in tests
+var SimulationClock clock.Clock = clock.Real
+
+// Shortest step of the catch-up, so that rounding errors can't make it loop forever
+const catchUpMinimumStep = 0.001 // Seconds
+
+// Simulation loop, moving the online spaceships and producing their items until the time of its clock
+type Simulation struct {
+	clock clock.Clock
+}
+
+func NewSimulation(c clock.Clock) *Simulation {
+	return &Simulation{clock: c}
+}
+
+// Simulates the time passed since the previous step.
+// Must not be called while the loop is running.
+func (simulation *Simulation) Step() {
+	Simulate(simulation.clock.Now())
+}
+
+// Runs a step after each delay. The returned function stops the loop, waiting for the end of the current step.
+func (simulation *Simulation) Start(delay time.Duration) (stop func()) {
+	stopping := make(chan struct{})
+	stopped  := make(chan struct{})
+	
+	go func() {
+		defer close(stopped)
+		
+		for {
+			timer := simulation.clock.NewTimer(delay)
+			select {
+				case <-timer.C():
+				case <-stopping:
+					timer.Stop()
+					return
+			}
+			simulation.Step()
+		}
+	}()
+	
+	return func() {
+		close(stopping)
+		<-stopped
+	}
+}
+
+// Date of the simulation in the database
+func simulationTime(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+// Moves the spaceships of the online users and produces and consumes their items, for the time passed
+// since they have been simulated. Sends them the new item states, and their positions to the users within range.
+func Simulate(now time.Time) {
+	simulatedAt := simulationTime(now)
+	
+	var positions map[*User]model.Vec3
+	db.DeferredTransaction(func(tx db.Store) bool {
+		for _, spaceShipId := range tx.GetOnlineSpaceShipIds() {
+			moveSpaceShip(tx, spaceShipId, simulatedAt - tx.GetSpaceShipSimulatedAt(spaceShipId))
+		}
+		
+		tx.PutDataIntoItemVariation(simulatedAt)
+		tx.UpdateItemVariationFromTemp()
+		
+		tx.InsertIntoEmptiedBuildingsFromItemVariation()
+		tx.UpdateEmptiedBuildingsFromTemp()
+		
+		users := Users.Snapshot(true)
+		for _, user := range users {
+			user.SendItemVariation(tx)
+			user.SendDisabledBuildings(tx)
+			user.broadcastPosition(tx)
+		}
+		positions = spaceShipPositions(tx, users)
+		
+		tx.TruncateItemVariation()
+		tx.TruncateEmptiedBuildings()
+		tx.SetOnlineSpaceShipsSimulatedAt(simulatedAt)
+		
+		return true
+	})
+	
+	// The spaceships which moved out of sight aren't sent anymore
+	updateInterests(positions)
+}
+
+// Applies what happened to a spaceship while it was offline, since the last time it has been simulated :
+// it drifts, and its items are produced and consumed. The time of the production is split each time an
+// item becomes empty or full, so that the buildings which run out of items midway stop at this time.
+// Must be called before the spaceship is online.
+func CatchUpSimulation(spaceShipId int64, now time.Time) {
+	simulatedAt := simulationTime(now)
+	
+	db.DeferredTransaction(func(tx db.Store) bool {
+		remaining := simulatedAt - tx.GetSpaceShipSimulatedAt(spaceShipId)
+		driftSpaceShip(tx, spaceShipId, remaining)
+		
+		for remaining > 0 {
+			step, found := tx.GetNextItemVariationEnd(spaceShipId)
+			if !found {
+				break
+			}
+			step = math.Max(math.Min(step, remaining), catchUpMinimumStep)
+			
+			tx.PutSpaceShipDataIntoItemVariation(spaceShipId, step)
+			tx.UpdateItemVariationFromTemp()
+			
+			tx.InsertIntoEmptiedBuildingsFromItemVariation()
+			tx.UpdateEmptiedBuildingsFromTemp()
+			
+			tx.TruncateItemVariation()
+			tx.TruncateEmptiedBuildings()
+			
+			remaining -= step
+		}
+		
+		tx.SetSpaceShipSimulatedAt(spaceShipId, simulatedAt)
+		return true
+	})
+}
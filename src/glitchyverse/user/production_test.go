@@ -0,0 +1,80 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"testing"
+	"time"
+	"glitchyverse/clock"
+	"glitchyverse/content"
+	"glitchyverse/database"
+	"glitchyverse/model"
+)
+
+func useMemoryStore(t *testing.T) {
+	pack, err := content.Load("../../../content")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Use(content.NewMemoryStore(pack))
+}
+
+// An offline spaceship keeps its velocity
+func TestCatchUpSimulationDrift(t *testing.T) {
+	useMemoryStore(t)
+	
+	_, userId := db.InsertUser("ann", "hash")
+	_, spaceShipId := db.InsertSpaceShip(userId, "Ann's ship")
+	db.SetSpaceShipMotion(spaceShipId, model.Vec3{1, 2, 3}, model.Quat{0, 0, 0, 1}, model.Vec3{0, 0, -2}, model.Vec3{})
+	db.SetSpaceShipSimulatedAt(spaceShipId, 100)
+	
+	CatchUpSimulation(spaceShipId, time.Unix(110, 0))
+	
+	spaceShip, _ := db.GetSpaceShip(spaceShipId)
+	if want := (model.Vec3{1, 2, -17}); spaceShip.Position != want {
+		t.Errorf("got position %v, want %v", spaceShip.Position, want)
+	}
+	if want := (model.Vec3{0, 0, -2}); spaceShip.Velocity != want {
+		t.Errorf("got velocity %v, want %v", spaceShip.Velocity, want)
+	}
+	if simulatedAt := db.GetSpaceShipSimulatedAt(spaceShipId); simulatedAt != 110 {
+		t.Errorf("got simulatedAt %v, want 110", simulatedAt)
+	}
+}
+
+// The timer waited for by the simulation loop is forgotten once it's stopped
+func TestSimulationStop(t *testing.T) {
+	c := clock.NewManual(time.Unix(100, 0))
+	stop := NewSimulation(c).Start(time.Second)
+	
+	for c.Waiting() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	
+	if c.Waiting() != 0 {
+		t.Errorf("got %d waiting timers after the stop, want 0", c.Waiting())
+	}
+}
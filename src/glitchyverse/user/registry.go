@@ -0,0 +1,147 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"sync"
+)
+
+// Connected users. Every socket is registered, and authenticated users are
+// also indexed by user id, spaceship id and user name.
+type Registry struct {
+	mutex         sync.RWMutex
+	users         map[*User]bool
+	byUserId      map[int64]*User
+	bySpaceShipId map[int64]*User
+	byName        map[string]*User
+}
+
+// The users of the server
+var Users = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		users:         make(map[*User]bool),
+		byUserId:      make(map[int64]*User),
+		bySpaceShipId: make(map[int64]*User),
+		byName:        make(map[string]*User),
+	}
+}
+
+func (r *Registry) Add(user *User) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	r.users[user] = true
+}
+
+// Indexes an authenticated user, unless another session of the same account is already indexed.
+// With takeOver, the other session is replaced and returned, so that it can be disconnected.
+// The ids and name of the user must not change afterwards.
+func (r *Registry) Claim(user *User, takeOver bool) (previous *User, claimed bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	previous = r.byUserId[user.UserId]
+	if previous != nil && !takeOver {
+		return previous, false
+	}
+	
+	r.users[user] = true
+	r.byUserId[user.UserId] = user
+	r.bySpaceShipId[user.SpaceShipId] = user
+	r.byName[user.UserName] = user
+	
+	return previous, true
+}
+
+func (r *Registry) Remove(user *User) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	delete(r.users, user)
+	
+	// Indexes may already refer to another session of the same account
+	if r.byUserId[user.UserId] == user {
+		delete(r.byUserId, user.UserId)
+	}
+	if r.bySpaceShipId[user.SpaceShipId] == user {
+		delete(r.bySpaceShipId, user.SpaceShipId)
+	}
+	if r.byName[user.UserName] == user {
+		delete(r.byName, user.UserName)
+	}
+}
+
+// Returns nil if the user isn't connected
+func (r *Registry) ByUserId(userId int64) *User {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.byUserId[userId]
+}
+
+// Returns nil if the owner of the spaceship isn't connected
+func (r *Registry) BySpaceShipId(spaceShipId int64) *User {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.bySpaceShipId[spaceShipId]
+}
+
+// Returns nil if the user isn't connected
+func (r *Registry) ByName(name string) *User {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.byName[name]
+}
+
+// Returns a copy of the list of users, which can be iterated without locking the registry.
+// If onlyAuthenticated is true, users which are not logged in yet are ignored.
+func (r *Registry) Snapshot(onlyAuthenticated bool) []*User {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	users := make([]*User, 0, len(r.users))
+	if onlyAuthenticated {
+		for _, user := range r.byUserId {
+			users = append(users, user)
+		}
+	} else {
+		for user := range r.users {
+			users = append(users, user)
+		}
+	}
+	
+	return users
+}
+
+func (r *Registry) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return len(r.users)
+}
@@ -0,0 +1,114 @@
+/**
+ * The MIT License (MIT)
+ * 
+ * Copyright (c) 2015 Sébastien CAPARROS (GlitchyVerse)
+ * 
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ * 
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ * 
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ */
+
+package user
+
+import (
+	"log"
+	"time"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"glitchyverse/database"
+	"glitchyverse/protocol"
+)
+
+const sessionTokenSize = 32 // Random bytes
+
+var SessionTokenLifetime = 30 * 24 * time.Hour // Can be changed by the configuration
+
+// Connects the user with a token received after a previous login, instead of a password
+func (user *User) ResumeSession(token string) bool {
+	if user.UserId > 0 {
+		return false
+	}
+	
+	sessionId, userId, userName := db.UseSession(hashSessionToken(token), time.Now().Unix())
+	if sessionId <= 0 {
+		user.sendAuthResult(false, "Your session has expired, please log in again.")
+		return false
+	}
+	
+	user.SessionId = sessionId
+	return user.login(userId, userName)
+}
+
+// Creates a new resumable session and sends its token to the user
+func (user *User) issueSessionToken() {
+	randomBytes := make([]byte, sessionTokenSize)
+	if _, err := rand.Read(randomBytes); err != nil {
+		log.Panic(err)
+	}
+	token := hex.EncodeToString(randomBytes)
+	
+	now := time.Now()
+	expiresAt := now.Add(SessionTokenLifetime)
+	user.SessionId = db.InsertSession(user.UserId, hashSessionToken(token), user.sessionLabel(), now.Unix(), expiresAt.Unix())
+	
+	user.SendMessage("sessionToken", struct{
+		Token     string `json:"token"`
+		ExpiresAt int64  `json:"expiresAt"`
+	}{token, expiresAt.Unix()})
+}
+
+// Sends the list of the active sessions of the user
+func (user *User) SendSessions() {
+	type session struct {
+		Id         int64  `json:"id"`
+		Label      string `json:"label"`
+		CreatedAt  int64  `json:"createdAt"`
+		LastUsedAt int64  `json:"lastUsedAt"`
+		ExpiresAt  int64  `json:"expiresAt"`
+		IsCurrent  bool   `json:"isCurrent"`
+	}
+	
+	sessions := make([]session, 0)
+	db.GetSessions(user.UserId, time.Now().Unix(), func(id int64, label string, createdAt, lastUsedAt, expiresAt int64) {
+		sessions = append(sessions, session{id, label, createdAt, lastUsedAt, expiresAt, id == user.SessionId})
+	})
+	
+	user.SendMessage("data_sessions", sessions)
+}
+
+// The session can't be resumed anymore. The connected users are not disconnected.
+func (user *User) RevokeSession(sessionId int64) error {
+	if !db.RevokeSession(user.UserId, sessionId) {
+		return protocol.Refused("Unknown session")
+	}
+	return nil
+}
+
+// Describes where the session comes from, to help the user to recognize it in the list
+func (user *User) sessionLabel() string {
+	host := user.RemoteIp()
+	if user.UserAgent != "" {
+		return host + " - " + user.UserAgent
+	}
+	return host
+}
+
+func hashSessionToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -25,89 +25,226 @@
 package user
 
 import (
+	"fmt"
 	"log"
+	"math"
+	"sync"
 	"time"
+	"errors"
 	"strconv"
 	"github.com/gorilla/websocket"
+	"glitchyverse/anticheat"
+	"glitchyverse/flight"
 	"glitchyverse/space"
+	"glitchyverse/model"
 	"glitchyverse/database"
-	"encoding/json"
-	"crypto/sha1"
-	"encoding/hex"
+	"glitchyverse/protocol"
 )
 
-const (
-	SpaceShipMaxSpeedPerPropellerUnit = 20
-	MoveMaximumErrorRate = 0.1 // The maximum difference rate when the client sends new position
+// Can be changed by the configuration
+var (
+	SpaceShipMaxSpeedPerPropellerUnit = 20.0
+	SpaceShipThrustPerPropellerUnit   = 60.0 // Force of a propeller unit at state 1
+	BuildingUnitMass                  = 10.0
+	ItemMass                          = 1.0
+	PositionTolerance                 = 5.0  // Maximum distance between the position predicted by the client and the server one
+	RotationTolerance                 = 5.0  // Same for the rotation, in degrees
+	TeleportDistance                  = 50.0 // Beyond it, a wrong predicted position is a teleport
+	AccelerationTolerance             = 5.0  // Predicted acceleration allowed beyond the one of the propellers, in units per second²
+	AngularAccelerationTolerance      = 20.0 // Same for the angular acceleration, in degrees per second²
 )
 
 type User struct {
 	Socket *websocket.Conn
+	Protocol int // Version negotiated during the handshake
+	outbound *outboundQueue
 	UserId int64
 	SpaceShipId int64
-	Name string
-	Position [3]float64
-	Rotation [3]float64
-	lastPositionUpdateTime time.Time
+	SessionId int64 // Resumable session used by the user
+	UserName string
+	UserAgent string
+	ClientDefinitionsHash string // Hash of the definitions cached by the client
+	Name string // Name of the spaceship
+	Position model.Vec3 // Computed by the server, updated when the client sends its predicted position
+	Rotation model.Quat
+	inputSequence int64 // Highest sequence number of the movement messages of the client
+	lastReport movementReport // Last measured movement predicted by the client
+	knownSpaceShips map[int64]bool // Other spaceships whose data has been sent to the client, guarded by interests
+	disconnectOnce sync.Once
+	closeAfterReply bool
 }
 
-var users = make(map[*User]bool)
+// What to do when a user logs in while its account is already connected
+type DoubleLoginPolicy int
+
+const (
+	DoubleLoginTakeOver DoubleLoginPolicy = iota // The old session is disconnected and the new one takes its spaceship
+	DoubleLoginReject                            // The new session is refused
+)
+
+var OnDoubleLogin = DoubleLoginTakeOver
 
-// TODO block double login
+func ParseDoubleLoginPolicy(name string) (DoubleLoginPolicy, error) {
+	switch name {
+		case "takeover":
+			return DoubleLoginTakeOver, nil
+		case "reject":
+			return DoubleLoginReject, nil
+		default:
+			return DoubleLoginTakeOver, errors.New("Unknown double login policy : " + name)
+	}
+}
 
-func NewUser(socket *websocket.Conn) *User {
-	user := &User{Socket: socket}
-	users[user] = true // TODO synchronize it ?
+func NewUser(socket *websocket.Conn, protocolVersion int) *User {
+	user := &User{
+		Socket:          socket,
+		Protocol:        protocolVersion,
+		outbound:        newOutboundQueue(socket),
+		knownSpaceShips: make(map[int64]bool),
+	}
+	Users.Add(user)
 	user.SendMessage("authQuery", nil)
 	return user
 }
 
+// Calls the callback for each authenticated user
 func LoopUsers(callBack func(user *User)) {
-	for k := range users {
+	for _, k := range Users.Snapshot(true) {
 		callBack(k)
 	}
 }
 
-func (user *User) GetPosition() [3]float64 {
+func (user *User) GetPosition() model.Vec3 {
 	return user.Position
 }
 
+// Can be called multiple times, only the first call has an effect
 func (user *User) Disconnect() {
-	user.SendMessageBroadcast("deleteSpaceship", user.SpaceShipId, true)
-	db.DeleteUserOnline(user.UserId)
-	delete(users, user) // TODO synchronize it ?
+	user.disconnectOnce.Do(user.disconnect)
+}
+
+// Disconnects a session replaced by a new one of the same account.
+// The new session then loads the spaceship from the database and sends it again to everyone.
+// The old socket is closed in the background : a stalled client must not delay the new login.
+func (user *User) takeOver() {
+	user.disconnectOnce.Do(func() {
+		user.SendMessage("sessionTakenOver", struct{
+			Message string `json:"message"`
+		}{"Your account has been connected from another place."})
+		user.SetCloseReason(websocket.CloseNormalClosure, "sessionTakenOver")
+		
+		user.leave()
+		go user.closeSocket()
+	})
+}
+
+func (user *User) disconnect() {
+	user.leave()
+	user.closeSocket()
+}
+
+// Removes the user from the registry and from the online spaceships
+func (user *User) leave() {
+	Users.Remove(user)
+	if user.UserId > 0 {
+		user.leaveInterests()
+		db.DeleteUserOnline(user.UserId)
+	}
+}
+
+// Can wait for the write timeout if the client doesn't read its messages
+func (user *User) closeSocket() {
+	user.outbound.close() // Sending what is left before closing
 	user.Socket.Close()
 }
 
+// Defines the close frame sent when the user is disconnected, after the remaining messages
+func (user *User) SetCloseReason(closeCode int, text string) {
+	user.outbound.setCloseMessage(websocket.FormatCloseMessage(closeCode, text))
+}
+
+// Stops the socket reader of the user from another goroutine. The request being handled
+// is finished, then the user is disconnected like when the client leaves.
+func (user *User) Interrupt(closeCode int, text string) {
+	user.SetCloseReason(closeCode, text)
+	user.Socket.SetReadDeadline(time.Now())
+}
+
+// Sends a message to every user, even if not authenticated yet
+func SendMessageToAll(method string, data interface{}) {
+	for _, k := range Users.Snapshot(false) {
+		k.SendMessage(method, data)
+	}
+}
+
+// Asks the socket reader to disconnect the user once the current request has been answered
+func (user *User) CloseAfterReply(closeCode int, text string) {
+	user.SetCloseReason(closeCode, text)
+	user.closeAfterReply = true
+}
+
+// True if the user must be disconnected after answering to the current request
+func (user *User) MustClose() bool {
+	return user.closeAfterReply
+}
+
 func (user *User) SendMessage(method string, data interface{}) {
-	jsonData, err := json.Marshal(data)
+	user.sendFrame(0, method, data)
+}
+
+// Answers to a client request. Legacy clients don't send request ids, so they only receive the errors.
+func (user *User) SendReply(requestId int64, err *protocol.Error) {
 	if err != nil {
-		log.Panic(err)
+		user.sendFrame(requestId, protocol.MethodError, err)
+	} else if requestId != 0 && user.Protocol != protocol.VersionLegacy {
+		user.sendFrame(requestId, protocol.MethodAck, nil)
 	}
-	
-	err = user.Socket.WriteMessage(websocket.TextMessage, []byte(method + "#" + string(jsonData)))
+}
+
+// Queues a message, it will be written by the writer of the user
+func (user *User) sendFrame(replyTo int64, method string, data interface{}) {
+	frame, err := protocol.Encode(user.Protocol, replyTo, method, data)
 	if err != nil {
 		log.Panic(err)
 	}
+	
+	user.outbound.push(frame, "")
 }
 
 func (user *User) SendMessageBroadcast(method string, data interface{}, exceptCurrentUser bool) {
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		log.Panic(err)
-	}
+	user.broadcast(method, data, exceptCurrentUser, "")
+}
+
+// Messages with the same coalesceKey replace each other while they are waiting in the queue
+// of a user, and can be dropped for slow users ("" = never coalesced nor dropped).
+// The other users only get the message if their client knows the spaceship of the user.
+func (user *User) broadcast(method string, data interface{}, exceptCurrentUser bool, coalesceKey string) {
+	// Encoding the message only once for each protocol version
+	frames := make(map[int][]byte)
+	
+	interests.RLock()
+	defer interests.RUnlock()
 	
-	for k := range users {
-		if !exceptCurrentUser || k != user {
-			err = k.Socket.WriteMessage(websocket.TextMessage, []byte(method + "#" + string(jsonData)))
-			if err != nil {
-				log.Panic(err)
+	for _, k := range Users.Snapshot(true) {
+		// During a session takeover, the new session of the account is the current user too
+		isCurrentUser := k == user || k.UserId == user.UserId
+		if (isCurrentUser && !exceptCurrentUser) || (!isCurrentUser && k.knownSpaceShips[user.SpaceShipId]) {
+			frame, ok := frames[k.Protocol]
+			if !ok {
+				var err error
+				frame, err = protocol.Encode(k.Protocol, 0, method, data)
+				if err != nil {
+					log.Panic(err)
+				}
+				frames[k.Protocol] = frame
 			}
+			
+			k.outbound.push(frame, coalesceKey)
 		}
 	}
 }
 
-func (user *User) SendSpaceContent(data []space.Body) {
+func (user *User) SendSpaceContent(data []model.Body) {
 	user.SendMessage("data_spaceContent", data)
 }
 
@@ -115,54 +252,101 @@ func (user *User) SendVisibleChunks() {
 	space.SendVisibleChunks(user)
 }
 
-func (user *User) Connect(name, password string) {
+func (user *User) Connect(name, password string) bool {
 	if user.UserId > 0 {
-		return
+		return false
 	}
 	
-	encodedPass := sha1.Sum([]byte(name + password))
-	hashPass := hex.EncodeToString(encodedPass[:])
-	user.UserId = db.GetUserId(name, hashPass)
+	ip := user.RemoteIp()
+	if allowed, retryAfter := Logins.Attempt(name, ip); !allowed {
+		user.sendAuthResult(false, fmt.Sprintf(
+			"Too many failed attempts, please retry in %d seconds.",
+			int64(math.Ceil(retryAfter.Seconds())),
+		))
+		return false
+	}
 	
-	result := struct{
-		Message string `json:"message"`
-		IsValid bool   `json:"isValid"`
-	}{}
+	userId, passwordHash := db.GetUserCredentials(name)
+	valid := false
+	if userId > 0 {
+		var needsRehash bool
+		valid, needsRehash = checkPassword(name, password, passwordHash)
+		if valid && needsRehash {
+			if newHash, err := hashPassword(password); err == nil {
+				db.SetUserPassword(userId, newHash)
+			} else {
+				log.Println(err)
+			}
+		}
+	}
 	
-	result.IsValid = (user.UserId > 0)
+	if !valid {
+		Logins.Failed(name, ip)
+		user.sendAuthResult(false, "Name or password isn't valid.")
+		return false
+	}
+	Logins.Succeeded(name, ip)
 	
-	if result.IsValid {
-		user.SpaceShipId = db.GetFirstSpaceShipId(user.UserId)
-		user.Name, user.Position, user.Rotation, _ = db.GetSpaceShip(user.SpaceShipId)
-		user.lastPositionUpdateTime = time.Now()
-		result.Message = "Connection success !"
-	} else {
-		result.Message = "Name or password isn't valid."
+	if !user.login(userId, name) {
+		return false
 	}
 	
-	user.SendMessage("authResult", result)
+	user.issueSessionToken()
+	return true
+}
+
+// Logs in an authenticated user and sends it the game data
+func (user *User) login(userId int64, name string) bool {
+	user.UserId = userId
+	user.UserName = name
+	user.SpaceShipId = db.GetFirstSpaceShipId(user.UserId)
 	
-	if result.IsValid {
-		db.InsertUserOnline(user.UserId, user.SpaceShipId)
-		
-		// Sending types data (TODO cache this data instead of re-creating it every time)
-		user.SendBuildingTypesDefinition()
-		user.SendItemGroupsDefinition()
-		user.SendItemTypesDefinition()
-		
-		// Sending spaceship data
-		user.SendSpaceShipData()
-		user.SendVisibleChunks()
+	previous, claimed := Users.Claim(user, OnDoubleLogin == DoubleLoginTakeOver)
+	if !claimed {
+		user.UserId, user.SpaceShipId, user.UserName, user.SessionId = 0, 0, "", 0
+		user.sendAuthResult(false, "This account is already connected.")
+		return false
 	}
+	
+	if previous != nil {
+		// Must be done before loading the spaceship and inserting the new online row
+		previous.takeOver()
+	}
+	
+	// Applying what happened while the spaceship was offline
+	CatchUpSimulation(user.SpaceShipId, SimulationClock.Now())
+	
+	spaceShip, _ := db.GetSpaceShip(user.SpaceShipId)
+	user.Name, user.Position, user.Rotation = spaceShip.Name, spaceShip.Position, spaceShip.Rotation
+	user.sendAuthResult(true, "Connection success !")
+	
+	db.InsertUserOnline(user.UserId, user.SpaceShipId)
+	
+	// Sending types data, unless the client already has them
+	user.sendDefinitions()
+	
+	// Sending spaceship data, then the spaceships around
+	user.SendSpaceShipData()
+	updateAllInterests()
+	user.SendVisibleChunks()
+	
+	return true
+}
+
+func (user *User) sendAuthResult(isValid bool, message string) {
+	user.SendMessage("authResult", struct{
+		Message string `json:"message"`
+		IsValid bool   `json:"isValid"`
+	}{message, isValid})
 }
 
-func (user *User) SendItemVariation() {
+func (user *User) SendItemVariation(store db.Store) {
 	result := struct{
 		SpaceShipId int64         `json:"spaceshipId"`
 		Items       []interface{} `json:"items"`
 	}{user.SpaceShipId, make([]interface{}, 0)}
 	
-	db.GetNewStatesFromItemVariation(user.SpaceShipId, func(itemId int64, newItemState float64, buildingId int64) {
+	store.GetNewStatesFromItemVariation(user.SpaceShipId, func(itemId int64, newItemState float64, buildingId int64) {
 		result.Items = append(result.Items, struct{
 			ItemId       int64   `json:"itemId"`
 			NewItemState float64 `json:"newItemState"`
@@ -175,13 +359,13 @@ func (user *User) SendItemVariation() {
 	}
 }
 
-func (user *User) SendDisabledBuildings() {
+func (user *User) SendDisabledBuildings(store db.Store) {
 	result := struct{
 		SpaceShipId int64   `json:"spaceshipId"`
 		BuildingIds []int64 `json:"buildingIds"`
 	}{user.SpaceShipId, make([]int64, 0)}
 	
-	db.GetNewStatesFromItemVariation(user.SpaceShipId, func(itemId int64, newItemState float64, buildingId int64) {
+	store.GetNewStatesFromItemVariation(user.SpaceShipId, func(itemId int64, newItemState float64, buildingId int64) {
 		result.BuildingIds = append(result.BuildingIds, buildingId)
 	})
 	
@@ -190,96 +374,58 @@ func (user *User) SendDisabledBuildings() {
 	}
 }
 
-func (user *User) SendSpaceShipData() {
-	// Preparing items list
-	itemsListById := make(map[string][]interface{})
-	db.GetItems(user.SpaceShipId, func(id, typeId int64, state float64, buildingId int64, slotGroupId *int64) {
-		itemList, ok := itemsListById[strconv.FormatInt(buildingId, 10)]
-		if !ok {
-			itemList = make([]interface{}, 0)
-		}
-		
-		itemsListById[strconv.FormatInt(buildingId, 10)] = append(itemList, struct{
-			Id          int64   `json:"id"`
-			TypeId      int64   `json:"typeId"`
-			State       float64 `json:"state"`
-			SlotGroupId *int64  `json:"slotGroupId"`
-		}{id, typeId, state, slotGroupId})
-	})
+// Content of the data_spaceship messages
+type spaceShipMessage struct {
+	model.Spaceship
+	Owner      bool                   `json:"owner"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// Returns the spaceship with its buildings and their items
+func newSpaceShipMessage(spaceShipId int64, owner bool) spaceShipMessage {
+	// Putting the items in their buildings
+	itemsByBuilding := make(map[int64][]model.Item)
+	for _, item := range db.GetItems(spaceShipId) {
+		itemsByBuilding[item.BuildingId] = append(itemsByBuilding[item.BuildingId], item)
+	}
 	
-	// Preparing buildings list
-	buildings := make([]interface{}, 0)
-	db.GetBuildings(user.SpaceShipId, -1, func(
-		id int64,
-		typeId int64,
-		position [3]float64,
-		rotation [4]float64,
-		size [3]float64,
-		state float64,
-		isBuilt bool,
-		seed *string,
-		isEnabled bool,
-	) {
-		itemList, ok := itemsListById[strconv.FormatInt(id, 10)]
-		if !ok {
-			itemList = make([]interface{}, 0)
+	spaceShip, _ := db.GetSpaceShip(spaceShipId)
+	spaceShip.Buildings = db.GetBuildings(spaceShipId, -1)
+	for i := range spaceShip.Buildings {
+		spaceShip.Buildings[i].Items = itemsByBuilding[spaceShip.Buildings[i].Id]
+		if spaceShip.Buildings[i].Items == nil {
+			spaceShip.Buildings[i].Items = make([]model.Item, 0)
 		}
-		
-		buildings = append(buildings, struct{
-			Id        int64         `json:"id"`
-			TypeId    int64         `json:"typeId"`
-			Position  [3]float64    `json:"position"`
-			Rotation  [4]float64    `json:"rotation"`
-			Size      [3]float64    `json:"size"`
-			State     float64       `json:"state"`
-			IsBuilt   bool          `json:"isBuilt"`
-			IsEnabled bool          `json:"isEnabled"`
-			Seed      *string       `json:"seed"`
-			Items     []interface{} `json:"items"`
-		}{
-			id,
-			typeId,
-			position,
-			rotation,
-			size,
-			state,
-			isBuilt,
-			isEnabled,
-			seed,
-			itemList,
-		})
-	})
+	}
 	
-	// Preparing data to send
-	spaceShipData := struct{
-		Id         int64                  `json:"id"`
-		Owner      bool                   `json:"owner"`
-		Name       string                 `json:"name"`
-		Position   [3]float64             `json:"position"`
-		Rotation   [3]float64             `json:"rotation"`
-		Buildings  []interface{}          `json:"buildings"`
-		Attributes map[string]interface{} `json:"attributes"`
-	}{
-		user.SpaceShipId,
-		true,
-		user.Name,
-		user.Position,
-		user.Rotation,
-		buildings,
+	return spaceShipMessage{
+		spaceShip,
+		owner,
 		map[string]interface{} {
+			// The client predicts the movements with the physics of the server
 			"maxSpeedPerPropellerUnit": SpaceShipMaxSpeedPerPropellerUnit,
+			"thrustPerPropellerUnit":   SpaceShipThrustPerPropellerUnit,
+			"buildingUnitMass":         BuildingUnitMass,
+			"itemMass":                 ItemMass,
 		},
 	}
-	
-	// Sending spaceship definition to user
-	user.SendMessage("data_spaceship", spaceShipData)
-	
-	// Sending spaceship definition to all users
-	spaceShipData.Owner = false
-	user.SendMessageBroadcast("data_spaceship", spaceShipData, true)
 }
 
-func (user *User) UpdatePropellers(propellerId int64, powerLevel float64) {
+// Sends its spaceship to the user. The other users get it when it's in their visible chunks.
+func (user *User) SendSpaceShipData() {
+	user.SendMessage("data_spaceship", newSpaceShipMessage(user.SpaceShipId, true))
+}
+
+// Returns false if the spaceship is frozen by Movements
+func (user *User) UpdatePropellers(propellerId int64, powerLevel float64, sequence int64) bool {
+	if Movements.IsFrozen(user.UserId, SimulationClock.Now()) {
+		return false
+	}
+	if !user.acceptSequence(sequence) {
+		return true
+	}
+	
+	// The new thrust applies from the previous tick of the simulation
 	db.SetPropellersPowerRate(user.SpaceShipId, propellerId, powerLevel)
 	
 	user.SendMessageBroadcast("updatePropellers", struct{
@@ -287,6 +433,7 @@ func (user *User) UpdatePropellers(propellerId int64, powerLevel float64) {
 		Id          int64   `json:"id"`
 		Power       float64 `json:"power"`
 	}{user.SpaceShipId, propellerId, powerLevel}, true)
+	return true
 }
 
 func (user *User) UpdateDoors(doorId int64, state float64) {
@@ -299,83 +446,107 @@ func (user *User) UpdateDoors(doorId int64, state float64) {
 	// TODO send information to other clients ?
 }
 
-// TODO use a type instead of a map of interface{} everywhere
+// The client predicts the movements of its spaceship, and sends its position with the sequence number
+// of the message. If its propellers can't do this movement, it's a violation scored by Movements, and the
+// user gets a correction, gets frozen or kicked depending on the score. The other predictions too far from
+// the movement computed by the server are only corrected.
+// Returns false if the position has been corrected, or if the message is older than a previous one.
+func (user *User) UpdatePosition(position model.Vec3, rotation model.Quat, sequence int64) bool {
+	if !user.acceptSequence(sequence) {
+		return false
+	}
+	
+	var motion flight.Motion
+	var ship flight.Ship
+	var found bool
+	db.DeferredTransaction(func(tx db.Store) bool {
+		motion, found = currentSpaceShipMotion(tx, user.SpaceShipId)
+		ship = spaceShipPhysics(tx, user.SpaceShipId)
+		return true
+	})
+	if !found {
+		return false
+	}
+	user.Position, user.Rotation = motion.Position, motion.Rotation
+	space.SendVisibleChunks(user)
+	
+	now := SimulationClock.Now()
+	report, ok := user.lastReport.measure(position, rotation, now)
+	kind := movementViolation(user.lastReport, report, motion, ship)
+	if ok {
+		user.lastReport = report
+	}
+	
+	response := anticheat.ResponseNone
+	if kind != "" {
+		// The predictions made before a freeze are only corrected
+		response = anticheat.ResponseRubberBand
+		if !Movements.IsFrozen(user.UserId, now) {
+			response = Movements.Violation(user.UserId, kind, now)
+		}
+	}
+	
+	switch response {
+		case anticheat.ResponseNone:
+			if isPredictionClose(position, rotation, motion) {
+				return true
+			}
+		case anticheat.ResponseFreeze:
+			motion = user.freeze()
+		case anticheat.ResponseKick:
+			user.kick()
+			return false
+	}
+	
+	user.lastReport = serverMovementReport(motion, now)
+	user.SendMessage("updatePosition", newPositionMessage(user.SpaceShipId, motion, sequence, true))
+	return false
+}
 
-func (user *User) UpdatePosition(position [3]float64, rotation [3]float64) {
-	time := time.Now()
-	passedTime := time.Sub(user.lastPositionUpdateTime)
-	user.lastPositionUpdateTime = time
+// Sends the position computed by the server to the other users
+func (user *User) broadcastPosition(store db.Store) {
+	motion, found := spaceShipMotion(store, user.SpaceShipId)
+	if !found {
+		return
+	}
 	
-	sendPositionToUser := false
-	if db.SetSpaceShipPosition(
-		user.SpaceShipId,
-		SpaceShipMaxSpeedPerPropellerUnit,
-		MoveMaximumErrorRate,
-		passedTime.Seconds(),
-		position,
-		rotation,
-	) {
-		user.Position = position
-		user.Rotation = rotation
-		space.SendVisibleChunks(user)
-	} else {
-		sendPositionToUser = true
-	}
-	
-	user.SendMessageBroadcast("updatePosition", struct{
-		SpaceshipId int64      `json:"spaceshipId"`
-		Position    [3]float64 `json:"position"`
-		Rotation    [3]float64 `json:"rotation"`
-	}{user.SpaceShipId, position, rotation}, !sendPositionToUser)
-}
-
-func (user *User) AddBuilding(typeId int64, position [3]float64, size [3]float64, rotation [4]float64) bool {
+	user.broadcast(
+		"updatePosition",
+		newPositionMessage(user.SpaceShipId, motion, 0, false),
+		true,
+		"updatePosition#" + strconv.FormatInt(user.SpaceShipId, 10),
+	)
+}
+
+// Movement messages without sequence number (0) are always accepted
+func (user *User) acceptSequence(sequence int64) bool {
+	if sequence == 0 {
+		return true
+	}
+	if sequence < user.inputSequence {
+		return false
+	}
+	user.inputSequence = sequence
+	return true
+}
+
+func (user *User) AddBuilding(typeId int64, position model.Vec3, size model.Vec3, rotation model.Quat) bool {
 	var inserted bool
 	var id int64
 	
-	db.DeferredTransaction(func() bool {
-		inserted, id = db.InsertBuilding(user.SpaceShipId, typeId, position, size, rotation)
+	db.DeferredTransaction(func(tx db.Store) bool {
+		inserted, id = tx.InsertBuilding(user.SpaceShipId, typeId, position, size, rotation)
 		return inserted
 	})
 	
 	if inserted {
-		db.GetBuildings(user.SpaceShipId, id, func(
-			id int64,
-			typeId int64,
-			position [3]float64,
-			rotation [4]float64,
-			size [3]float64,
-			state float64,
-			isBuilt bool,
-			seed *string,
-			isEnabled bool,
-		) {
+		for _, building := range db.GetBuildings(user.SpaceShipId, id) {
+			building.Items = make([]model.Item, 0) // Items array is always empty after creation
 			user.SendMessageBroadcast("addBuilding", struct{
-				Id          int64         `json:"id"`
-				TypeId      int64         `json:"typeId"`
-				SpaceshipId int64         `json:"spaceshipId"`
-				Position    [3]float64    `json:"position"`
-				Rotation    [4]float64    `json:"rotation"`
-				Size        [3]float64    `json:"size"`
-				State       float64       `json:"state"`
-				IsBuilt     bool          `json:"isBuilt"`
-				IsEnabled   bool          `json:"isEnabled"`
-				Seed        *string       `json:"seed"`
-				Items       []interface{} `json:"items"`
-			}{
-				id,
-				typeId,
-				user.SpaceShipId,
-				position,
-				rotation,
-				size,
-				state,
-				isBuilt,
-				isEnabled,
-				seed,
-				make([]interface{}, 0), // Items array is always empty after creation
-			}, false)
-		})
+				SpaceshipId int64 `json:"spaceshipId"`
+				model.Building
+			}{user.SpaceShipId, building}, false)
+		}
 	}
 	
 	return inserted
@@ -385,8 +556,8 @@ func (user *User) AddBuilding(typeId int64, position [3]float64, size [3]float64
 
 func (user *User) DeleteBuilding(buildingId int64) bool {
 	var ret bool
-	db.DeferredTransaction(func() bool {
-		if db.DeleteBuilding(user.SpaceShipId, buildingId) {
+	db.DeferredTransaction(func(tx db.Store) bool {
+		if tx.DeleteBuilding(user.SpaceShipId, buildingId) {
 			user.SendMessageBroadcast("deleteBuilding", struct{
 				BuildingId  int64 `json:"buildingId"`
 				SpaceshipId int64 `json:"spaceshipId"`
@@ -403,144 +574,39 @@ func (user *User) DeleteBuilding(buildingId int64) bool {
 	return ret
 }
 
-func (user *User) MoveItem(itemId int64, buildingId int64, slotGroupId int64) {
-	if db.MoveItem(user.SpaceShipId, itemId, buildingId, slotGroupId) {
-		// NOTE : the enabled state is implicitly updated client side by the "moveItem" action
-		db.SetBuildingEnabled(user.SpaceShipId, buildingId, true)
-		
-		user.SendMessage("moveItem", struct{
-			SpaceshipId       int64 `json:"spaceshipId"`
-			ItemId            int64 `json:"itemId"`
-			TargetBuildingId  int64 `json:"targetBuildingId"`
-			TargetSlotGroupId int64 `json:"targetSlotGroupId"`
-		}{user.SpaceShipId, itemId, buildingId, slotGroupId}) // TODO broadcast ?!?
+func (user *User) MoveItem(itemId int64, buildingId int64, slotGroupId int64) bool {
+	if !db.MoveItem(user.SpaceShipId, itemId, buildingId, slotGroupId) {
+		return false
 	}
+	
+	// NOTE : the enabled state is implicitly updated client side by the "moveItem" action
+	db.SetBuildingEnabled(user.SpaceShipId, buildingId, true)
+	
+	user.SendMessage("moveItem", struct{
+		SpaceshipId       int64 `json:"spaceshipId"`
+		ItemId            int64 `json:"itemId"`
+		TargetBuildingId  int64 `json:"targetBuildingId"`
+		TargetSlotGroupId int64 `json:"targetSlotGroupId"`
+	}{user.SpaceShipId, itemId, buildingId, slotGroupId}) // TODO broadcast ?!?
+	
+	return true
 }
 
-func (user *User) AchieveBuilding(buildingId int64) {
-	db.DeferredTransaction(func() bool {
-		if db.SetBuildingBuilt(user.SpaceShipId, buildingId) {
-			db.DeleteItems(user.SpaceShipId, buildingId)
+func (user *User) AchieveBuilding(buildingId int64) bool {
+	var ret bool
+	db.DeferredTransaction(func(tx db.Store) bool {
+		ret = tx.SetBuildingBuilt(user.SpaceShipId, buildingId)
+		if ret {
+			tx.DeleteItems(user.SpaceShipId, buildingId)
 			
 			user.SendMessage("achieveBuilding", struct{
 				SpaceshipId int64 `json:"spaceshipId"`
 				BuildingId  int64 `json:"buildingId"`
 			}{user.SpaceShipId, buildingId}) // TODO broadcast ?!?
-			
-			return true
-		} else {
-			return false
-		}
-	})
-}
-
-func (user *User) SendItemGroupsDefinition() {
-	definition := make(map[string]string)
-	db.GetItemGroups(func(id int64, name string) {
-		definition[strconv.FormatInt(id, 10)] = name
-	})
-	user.SendMessage("data_itemGroupsDefinition", definition)
-}
-
-func (user *User) SendItemTypesDefinition() {
-	itemGroups := make(map[string][]int64)
-	db.GetItemTypesInItemGroups(func(typeId, groupId int64) {
-		groups, ok := itemGroups[strconv.FormatInt(typeId, 10)]
-		if !ok {
-			groups = make([]int64, 0)
-		}
-		itemGroups[strconv.FormatInt(typeId, 10)] = append(groups, groupId)
-	})
-	
-	definition := make([]interface{}, 0)
-	db.GetItemTypes(func(id int64, name string, maxState float64) {
-		groups := itemGroups[strconv.FormatInt(id, 10)]
-		if groups == nil {
-			groups = make([]int64, 0)
-		}
-		
-		definition = append(definition, struct{
-			Id       int64   `json:"id"`
-			Name     string  `json:"name"`
-			MaxState float64 `json:"maxState"`
-			Groups   []int64 `json:"groups"`
-		}{id, name, maxState, groups})
-	})
-	
-	user.SendMessage("data_itemTypesDefinition", definition)
-}
-
-func (user *User) SendBuildingTypesDefinition() {
-	itemSlots := make(map[string][]interface{})
-	db.GetItemSlots(func(buildingTypeId, itemGroupId int64, whenBuilding bool, maxAmount int64, variation float64) {
-		slots, ok := itemSlots[strconv.FormatInt(buildingTypeId, 10)]
-		if !ok {
-			slots = make([]interface{}, 0)
-		}
-		itemSlots[strconv.FormatInt(buildingTypeId, 10)] = append(slots, struct{
-			Group          int64   `json:"group"`
-			WhenBuilding   bool    `json:"whenBuilding"`
-			MaximumAmount  int64   `json:"maximumAmount"`
-			StateVariation float64 `json:"stateVariation"`
-		}{itemGroupId, whenBuilding, maxAmount, variation})
-	})
-	
-	definition := make([]interface{}, 0)
-	db.GetBuildingTypes(func(
-		id int64,
-		name string,
-		category *string,
-		model string,
-		isGap bool,
-		defaultState float64,
-		isSizeable bool,
-		isContainer bool,
-		isInside *bool,
-		isPositionByRoomUnit bool,
-		minState float64,
-		maxState float64,
-		canExertThrust bool,
-		isControllable bool,
-	) {
-		slots := itemSlots[strconv.FormatInt(id, 10)]
-		if slots == nil {
-			slots = make([]interface{}, 0)
 		}
 		
-		definition = append(definition, struct{
-			Id                   int64         `json:"id"`
-			Name                 string        `json:"name"`
-			Category             *string       `json:"category"`
-			Model                string        `json:"model"`
-			IsGap                bool          `json:"isGap"`
-			DefaultState         float64       `json:"defaultState"`
-			IsSizeable           bool          `json:"isSizeable"`
-			IsContainer          bool          `json:"isContainer"`
-			IsInside             *bool         `json:"isInside"`
-			IsPositionByRoomUnit bool          `json:"isPositionByRoomUnit"`
-			MinState             float64       `json:"minState"`
-			MaxState             float64       `json:"maxState"`
-			CanExertThrust       bool          `json:"canExertThrust"`
-			IsControllable       bool          `json:"isControllable"`
-			Slots                []interface{} `json:"slots"`
-		}{
-			id,
-			name,
-			category,
-			model,
-			isGap,
-			defaultState,
-			isSizeable,
-			isContainer,
-			isInside,
-			isPositionByRoomUnit,
-			minState,
-			maxState,
-			canExertThrust,
-			isControllable,
-			slots,
-		})
+		return ret
 	})
 	
-	user.SendMessage("data_buildingTypesDefinition", definition)
+	return ret
 }
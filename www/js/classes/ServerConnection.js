@@ -29,24 +29,45 @@
  * @param World The world where to add entities transmitted by the server
  */
 var ServerConnection = function(serverName, resource, world) {
-	// Connecting
-	this.socket = new WebSocket("ws://" + serverName + "/" + (resource || ""));
+	// Connecting (the server falls back to the legacy protocol if it doesn't know the envelope one)
+	this.socket = new WebSocket("ws://" + serverName + "/" + (resource || ""), [ServerConnection.ENVELOPE_SUBPROTOCOL]);
 	
 	this.world = world;
 	this.world.server = this;
 	
+	this.protocolVersion = ServerConnection.PROTOCOL_LEGACY; // Known when the socket is opened
+	this.nextRequestId   = 1;
+	this.pendingRequests = {}; // key = request id, value = callback
+	this.closeMessage    = null; // Explanation given by the server before closing the connection
+	this.receivedDefinitions = null; // Definitions to cache once their hash is received
+	this.inputSequence   = 0; // Sequence number of the last movement message (position or propellers)
+	this.lastCorrection  = 0; // Sequence number of the last position correction applied
+	
 	// Defining actions
 	var self = this;
 	this.socket.addEventListener('open', function() {
-		
+		if(self.socket.protocol == ServerConnection.ENVELOPE_SUBPROTOCOL) {
+			self.protocolVersion = ServerConnection.PROTOCOL_ENVELOPE;
+		}
 	});
 	this.socket.addEventListener('message', function(event) {
 		var message = event.data;
-		var hashIndex = message.indexOf("#");
-		var method = message.substring(0, hashIndex)
-		var data = JSON.parse(message.substring(hashIndex + 1));
+		var method, data, replyTo = 0;
 		
-		if(self["_" + method]) {
+		if(self.protocolVersion == ServerConnection.PROTOCOL_ENVELOPE) {
+			var envelope = JSON.parse(message);
+			method  = envelope.method;
+			data    = envelope.data;
+			replyTo = envelope.replyTo || 0;
+		} else {
+			var hashIndex = message.indexOf("#");
+			method = message.substring(0, hashIndex)
+			data = JSON.parse(message.substring(hashIndex + 1));
+		}
+		
+		if(replyTo > 0) {
+			self._handleReply(replyTo, method, data);
+		} else if(self["_" + method]) {
 			self["_" + method](data);
 		} else {
 			throw new Error("Unknown method name : " + method);
@@ -54,7 +75,7 @@ var ServerConnection = function(serverName, resource, world) {
 	});
 	this.socket.addEventListener('close', function(event) { 
 		// TODO better way than alert
-		alert("Connection lost :(");
+		alert(self.closeMessage || "Connection lost :(");
 	});
 	this.socket.addEventListener('error', function(event) { 
 		// TODO better way than alert
@@ -62,32 +83,169 @@ var ServerConnection = function(serverName, resource, world) {
 	});
 };
 
+ServerConnection.PROTOCOL_LEGACY      = 1;
+ServerConnection.PROTOCOL_ENVELOPE    = 2;
+ServerConnection.ENVELOPE_SUBPROTOCOL = "glitchyverse.v2";
+
 /**
  * Sends a message to the server
  * @param {String} action What to do
  * @param {Object} data Anything related to the action. Can also be null
+ * @param {function} (optional) callBack Called with a boolean (true if the server accepted the request)
+ *                   and the refusal data. Never called with the legacy protocol.
+ */
+ServerConnection.prototype.sendMessage = function(method, data, callBack) {
+	if(this.protocolVersion == ServerConnection.PROTOCOL_ENVELOPE) {
+		var envelope = {v: this.protocolVersion, method: method, data: data};
+		if(callBack) {
+			envelope.id = this.nextRequestId++;
+			this.pendingRequests[envelope.id] = callBack;
+		}
+		this.socket.send(JSON.stringify(envelope));
+	} else {
+		var jsonData = JSON.stringify(data);
+		this.socket.send(method + "#" + jsonData);
+	}
+};
+
+/**
+ * @return int The sequence number of a new movement message, the server sending it back in its corrections
+ */
+ServerConnection.prototype.nextInputSequence = function() {
+	return ++this.inputSequence;
+};
+
+/**
+ * Calls the callback of a request when the server answers to it
+ * @param int The id of the request
+ * @param String "ack" if the request has been accepted, "error" otherwise
+ * @param Object The error (code, message and method) if the request has been refused
+ */
+ServerConnection.prototype._handleReply = function(requestId, method, data) {
+	var callBack = this.pendingRequests[requestId];
+	if(callBack) {
+		delete this.pendingRequests[requestId];
+		callBack(method == "ack", data);
+	}
+};
+
+/**
+ * Called when a request sent without callback fails (or always with the legacy protocol)
+ * @param Object The error (code, message and method)
+ */
+ServerConnection.prototype._error = function(data) {
+	// Refusals are already visible in the world (nothing happens), only unexpected errors are shown
+	if(data.code != "refused") {
+		// TODO better way than alert
+		alert("Server error on " + data.method + " : " + data.message);
+	}
+};
+
+ServerConnection.SESSION_TOKEN_STORAGE_KEY = "glitchyverse.sessionToken";
+ServerConnection.DEFINITIONS_STORAGE_KEY   = "glitchyverse.definitions";
+
+/**
+ * @return Object The definitions received during a previous connection (hash and messages), or null
+ */
+ServerConnection.prototype._getCachedDefinitions = function() {
+	var cache = window.localStorage ? localStorage.getItem(ServerConnection.DEFINITIONS_STORAGE_KEY) : null;
+	return cache ? JSON.parse(cache) : null;
+};
+
+/**
+ * @return String The hash of the cached definitions, sent to the server so that it doesn't send them again
  */
-ServerConnection.prototype.sendMessage = function(method, data) {
-	var jsonData = JSON.stringify(data);
-	this.socket.send(method + "#" + jsonData);
+ServerConnection.prototype._getCachedDefinitionsHash = function() {
+	var cache = this._getCachedDefinitions();
+	return cache ? cache.hash : "";
 };
 
 ServerConnection.prototype._authQuery = function(data) {
+	// Trying to resume the previous session before asking for the password
+	var token = window.localStorage ? localStorage.getItem(ServerConnection.SESSION_TOKEN_STORAGE_KEY) : null;
+	if(token) {
+		this.isResumingSession = true;
+		this.sendMessage("resumeSession", {token: token, definitionsHash: this._getCachedDefinitionsHash()});
+	} else {
+		this._openLoginForm();
+	}
+};
+
+ServerConnection.prototype._openLoginForm = function() {
 	var self = this;
 	LoginForm.open(this.world, function(name, password) {
-		self.sendMessage("authAnswer", {name: name, password: password});
+		self.sendMessage("authAnswer", {name: name, password: password, definitionsHash: self._getCachedDefinitionsHash()});
 	});
 };
 
 ServerConnection.prototype._authResult = function(data) {
+	if(this.isResumingSession) {
+		this.isResumingSession = false;
+		if(!data.isValid) {
+			localStorage.removeItem(ServerConnection.SESSION_TOKEN_STORAGE_KEY);
+			this._openLoginForm();
+		}
+		return;
+	}
+	
 	LoginForm.setMessage(data.message, data.isValid);
 	if(data.isValid) {
 		LoginForm.close();
 	}
 };
 
+ServerConnection.prototype._sessionToken = function(data) {
+	if(window.localStorage) {
+		localStorage.setItem(ServerConnection.SESSION_TOKEN_STORAGE_KEY, data.token);
+	}
+};
+
+ServerConnection.prototype._sessionTakenOver = function(data) {
+	this.closeMessage = data.message;
+};
+
+ServerConnection.prototype._kicked = function(data) {
+	this.closeMessage = data.message;
+};
+
+/**
+ * The definitions have been sent just before, they are cached for the next connections
+ */
+ServerConnection.prototype._definitionsHash = function(data) {
+	if(window.localStorage && this.receivedDefinitions) {
+		this.receivedDefinitions.hash = data.hash;
+		localStorage.setItem(ServerConnection.DEFINITIONS_STORAGE_KEY, JSON.stringify(this.receivedDefinitions));
+	}
+	this.receivedDefinitions = null;
+};
+
+/**
+ * The definitions cached by the client are the current ones, loading them
+ */
+ServerConnection.prototype._definitionsUpToDate = function(data) {
+	var cache = this._getCachedDefinitions();
+	for(var method in cache.messages) {
+		this["_" + method](cache.messages[method]);
+	}
+	this.receivedDefinitions = null;
+};
+
+/**
+ * Keeps a definition message received from the server, in order to cache it
+ */
+ServerConnection.prototype._keepDefinition = function(method, data) {
+	if(!this.receivedDefinitions) {
+		this.receivedDefinitions = {hash: null, messages: {}};
+	}
+	this.receivedDefinitions.messages[method] = data;
+};
+
+ServerConnection.prototype._serverShutdown = function(data) {
+	this.closeMessage = data.reason;
+};
+
 ServerConnection.prototype._data_spaceship = function(data) {
-	var ss = new SpaceShip(this.world, data.id, data.name, data.position, data.rotation, data.buildings, data.attributes);
+	var ss = new SpaceShip(this.world, data.id, data.name, data.position, data.rotation, data.velocity, data.angularVelocity, data.buildings, data.attributes);
 	this.world.add(ss);
 	if(data.owner) {
 		this.world.setUserSpaceShip(ss);
@@ -95,6 +253,7 @@ ServerConnection.prototype._data_spaceship = function(data) {
 };
 
 ServerConnection.prototype._data_buildingTypesDefinition = function(data) {
+	this._keepDefinition("data_buildingTypesDefinition", data);
 	for(var i = 0 ; i < data.length ; i++) {
 		var bt = new BuildingType(data[i]);
 		Building.types[bt.id] = bt;
@@ -118,9 +277,15 @@ ServerConnection.prototype._data_spaceContent = function(data) {
 };
 
 ServerConnection.prototype._updatePosition = function(data) {
+	// The corrections of the position predicted for the user spaceship can arrive out of order
+	if(data.correction) {
+		if(data.sequence < this.lastCorrection) return;
+		this.lastCorrection = data.sequence;
+	}
+	
+	// The prediction goes on from the movement of the server, velocities included
 	var ss = this.world.spaceShips[data.spaceshipId];
-	ss.setPosition(data.position);
-	ss.rotation = data.rotation;
+	ss.setMotion(data.position, data.rotation, data.velocity, data.angularVelocity);
 };
 
 ServerConnection.prototype._updatePropellers = function(data) {
@@ -141,10 +306,12 @@ ServerConnection.prototype._deleteSpaceship = function(data) {
 };
 
 ServerConnection.prototype._data_itemGroupsDefinition = function(data) {
+	this._keepDefinition("data_itemGroupsDefinition", data);
 	Item.groups = data;
 };
 
 ServerConnection.prototype._data_itemTypesDefinition = function(data) {
+	this._keepDefinition("data_itemTypesDefinition", data);
 	Item.types = {};
 	for(var i = 0 ; i < data.length ; i++) {
 		var it = new ItemType(data[i]);
@@ -132,7 +132,11 @@ World.prototype.setUserSpaceShip = function(spaceShip) {
 		var newPosition = self.userSpaceShip.getPosition();
 		if(newPosition[0] != lastPos[0] || newPosition[1] != lastPos[1] || newPosition[2] != lastPos[2]) {
 			vec3.copy(lastPos, newPosition);
-			self.server.sendMessage("updatePosition", {position: newPosition, rotation: self.userSpaceShip.rotation});
+			self.server.sendMessage("updatePosition", {
+				position: newPosition,
+				rotation: self.userSpaceShip.rotationQuat,
+				sequence: self.server.nextInputSequence()
+			});
 		}
 	}, this.userSpaceShipMoveTimerDelay, false);
 	
@@ -313,6 +313,11 @@ Building.prototype.regenDomInventoryItems = function() {
 						"itemId"      : Item.currentItemDragged.id,
 						"buildingId"  : self.id,
 						"slotGroupId": slotGroupId
+					}, function(isAccepted, refusal) {
+						if(!isAccepted) {
+							// TODO better way than alert
+							alert("The item can't be moved here : " + refusal.message);
+						}
 					});
 				}
 			});
@@ -327,6 +332,7 @@ Building.prototype.regenDomInventoryItems = function() {
 Building.prototype.addItem = function(item) {
 	this.items.push(item);
 	this.regenDomInventoryItems();
+	this.spaceShip.updateAcceleration(); // Items change the mass of the spaceship
 	
 	if(!this.isBuilt && this.items.length == this.type.getSlotsCount(this.isBuilt) * this.slotSizeMultiplicator) {
 		this.world.server.sendMessage("achieveBuildingQuery", this.id);
@@ -342,6 +348,7 @@ Building.prototype.removeItem = function(item) {
 	if(index >= 0) {
 		this.items.splice(index, 1);
 		this.regenDomInventoryItems();
+		this.spaceShip.updateAcceleration();
 	}
 };
 
@@ -352,6 +359,7 @@ Building.prototype.removeItem = function(item) {
 Building.prototype.achieveBuilding = function() {
 	this.isBuilt = true;
 	this.items = [];
+	this.spaceShip.updateAcceleration();
 	this.inventoryDom.changeWindowTitle(this._getInventoryWindowTitleName());
 	this.regenDomInventoryItems();
 	this.setColorMask(null);
@@ -433,6 +433,11 @@ Designer.prototype._createDOMConfirmActions = function() {
 			"position": ghost.gridPosition,
 			"size"    : ghost.gridSize,
 			"rotation": ghost.gridRotation
+		}, function(isAccepted, refusal) {
+			if(!isAccepted) {
+				// TODO better way than alert
+				alert("The building can't be placed here : " + refusal.message);
+			}
 		});
 		
 		self.selectedType.domElement.setAttribute("data-isSelected", false);
@@ -28,31 +28,44 @@
  * @param int The id of the spaceship
  * @param String The name of the spaceship
  * @param vec3 The position of the spaceship
- * @param vec3 The rotation of the spaceship
+ * @param quat The rotation of the spaceship (inverse of its orientation in the world)
+ * @param vec3 The velocity of the spaceship (units per second)
+ * @param vec3 The angular velocity of the spaceship (axis of the rotation, its length being the speed in degrees per second)
  * @param Array(Object) An object containing the definition of the content of the spaceship.
  * @param Object Containing the definition of some attributes : 
  *               - maxSpeedPerPropellerUnit : The max speed to add per propeller unit
+ *               - thrustPerPropellerUnit   : The force of a propeller unit at state 1
+ *               - buildingUnitMass         : The mass of a building per unit of size
+ *               - itemMass                 : The mass of an item
  */
-var SpaceShip = function(world, id, name, position, rotation, definition, attributes) {
+var SpaceShip = function(world, id, name, position, rotation, velocity, angularVelocity, definition, attributes) {
 	this.world = world;
 	this.id = id;
 	this.roomUnitSize = vec3.fromValues(4, 4, 4); // TODO put everything in obj, and remove those constants ? + rename to grid unit size
 	this.edgeSize = 0.2;
 	this.lightAndClimEdgeSize = 0.58;
-	this.recoilMaxSpeedRate = 0.2; // Relative to the normal max speed
 	this.maxSpeedPerPropellerUnit = attributes.maxSpeedPerPropellerUnit;
+	this.thrustPerPropellerUnit = attributes.thrustPerPropellerUnit;
+	this.buildingUnitMass = attributes.buildingUnitMass;
+	this.itemMass = attributes.itemMass;
 	
-	this._linearMaxSpeed = 0;
+	// Physical properties, computed like the server does (see flight.NewShip), in the coordinates of the grid
+	this._mass     = 0;
+	this._center   = vec3.create(); // Center of mass
+	this._inertia  = vec3.create(); // Moments of inertia around the axes passing through the center of mass
+	this._force    = vec3.create(); // Thrust of the propellers
+	this._torque   = vec3.create();
+	this._maxSpeed = 0; // 0 if there is no propeller
 	
 	// TODO models : replace the definition parameter by normal parameters
 	// TODO organize js classes in subfolders
 	
 	this.name = name;
-	this._position = position;
-	this.rotation = rotation;
-	this.linearSpeed = 0;
-	this._linearAcceleration = 0; // Acceleration per second
-	this.rotationSpeed = vec3.create();
+	this._position = vec3.clone(position);
+	this.rotationQuat = quat.clone(rotation);
+	this.rotation = quatToEuler(this.rotationQuat); // Euler angles (degrees) of rotationQuat, used to draw the spaceship
+	this.velocity = vec3.clone(velocity);
+	this.angularVelocity = vec3.clone(angularVelocity);
 	
 	this.lastPositionUpdateTime = TimerManager.lastUpdateTimeStamp;
 	
@@ -278,37 +291,71 @@ SpaceShip.prototype.update = function() {
 	this.updatePosition();
 };
 
+/**
+ * Changes the movement of the spaceship, for example with the one computed by the server
+ * @param vec3 The new position
+ * @param quat The new rotation
+ * @param vec3 The new velocity
+ * @param vec3 The new angular velocity
+ */
+SpaceShip.prototype.setMotion = function(position, rotation, velocity, angularVelocity) {
+	vec3.copy(this._position, position);
+	quat.copy(this.rotationQuat, rotation);
+	vec3.copy(this.velocity, velocity);
+	vec3.copy(this.angularVelocity, angularVelocity);
+	this.rotation = quatToEuler(this.rotationQuat);
+};
+
+/**
+ * Moves the spaceship since the last update, pushed by its propellers.
+ * This is the integration of the server (see flight.Motion.Accelerate), the predicted movement must match its one.
+ */
 SpaceShip.prototype.updatePosition = function() {
-	var passedTimeRate = (TimerManager.lastUpdateTimeStamp - this.lastPositionUpdateTime) / 1000;
-	
-	// Updating speed, depending on acceleration
-	var minSpeed = -this._linearMaxSpeed * this.recoilMaxSpeedRate;
+	var seconds = (TimerManager.lastUpdateTimeStamp - this.lastPositionUpdateTime) / 1000;
 	this.lastPositionUpdateTime = TimerManager.lastUpdateTimeStamp;
-	this.linearSpeed += this._linearAcceleration * passedTimeRate;
-	if(this.linearSpeed > this._linearMaxSpeed) this.linearSpeed = this._linearMaxSpeed;
-	if(this.linearSpeed < minSpeed) this.linearSpeed = minSpeed;
 	
-	// Stopping movement if required
-	if(this.linearSpeed < 1 && this.linearSpeed > -1 && this._linearAcceleration == 0) {
-		// TODO do it not only for 1 unit, but for 1% of the maximum speed
-		this.linearSpeed = 0;
+	var orientation = quat.create();
+	var acceleration = vec3.create();
+	var angularAcceleration = vec3.create();
+	var turn = quat.create();
+	var axis = vec3.create();
+	while(seconds > 0) {
+		var dt = Math.min(seconds, SpaceShip.integrationStep);
+		seconds -= dt;
+		quat.conjugate(orientation, this.rotationQuat);
+		
+		if(this._mass > 0) {
+			vec3.transformQuat(acceleration, this._force, orientation);
+			vec3.scale(acceleration, acceleration, 1 / this._mass);
+			vec3.scaleAndAdd(this._position, this._position, this.velocity, dt);
+			vec3.scaleAndAdd(this._position, this._position, acceleration, dt * dt / 2);
+			vec3.scaleAndAdd(this.velocity, this.velocity, acceleration, dt);
+			var speed = vec3.length(this.velocity);
+			if(this._maxSpeed > 0 && speed > this._maxSpeed) {
+				vec3.scale(this.velocity, this.velocity, this._maxSpeed / speed);
+			}
+			
+			for(var i = 0 ; i < 3 ; i++) {
+				angularAcceleration[i] = this._inertia[i] > 0 ? radToDeg(this._torque[i] / this._inertia[i]) : 0;
+			}
+			vec3.transformQuat(angularAcceleration, angularAcceleration, orientation);
+			vec3.scaleAndAdd(this.angularVelocity, this.angularVelocity, angularAcceleration, dt);
+		} else {
+			// Drifting
+			vec3.scaleAndAdd(this._position, this._position, this.velocity, dt);
+		}
+		
+		// Turning the orientation, then going back to the rotation
+		var angularSpeed = vec3.length(this.angularVelocity);
+		if(angularSpeed > 0) {
+			vec3.scale(axis, this.angularVelocity, 1 / angularSpeed);
+			quat.setAxisAngle(turn, axis, degToRad(angularSpeed * dt));
+			quat.multiply(orientation, turn, orientation);
+			quat.normalize(orientation, orientation);
+			quat.conjugate(this.rotationQuat, orientation);
+		}
 	}
-	
-	// Updating rotation
-	var rot = vec3.create(); // TODO don't create object here (and bottom with eulerToQuat), use global temp vars ?
-	vec3.scale(rot, this.rotationSpeed, passedTimeRate);
-	vec3.add(this.rotation, this.rotation, rot);
-	
-	// TODO always store rotations as quat everywhere ? Or radians ?
-	
-	// Updating SpaceShip position
-	var move = vec3.fromValues(0, 0, -this.linearSpeed * passedTimeRate);
-	var rotationQuat = eulerToQuat(this.rotation);
-	quat.invert(rotationQuat, rotationQuat);
-	vec3.transformQuat(move, move, rotationQuat);
-	vec3.add(this._position, this._position, move);
-	
-	this.checkCollisions();
+	this.rotation = quatToEuler(this.rotationQuat);
 	
 	// Updating entities positions and rotations
 	for(var k in this.entities) {
@@ -319,67 +366,82 @@ SpaceShip.prototype.updatePosition = function() {
 };
 
 /**
- * Checks that the spaceship is not colliding with space objects (planets ...), and remains in orbit.
+ * Computes the mass, the inertia and the thrust of the spaceship, from its buildings, items and propellers.
+ * Must be called when one of them changes.
  */
-SpaceShip.prototype.checkCollisions = function() {
-	// Determining the radius of the "hitbox", based on highest absolute coordinates
-	var highestCoord = vec3.fromValues(
-		Math.max(Math.abs(this.minBounds[0]), Math.abs(this.maxBounds[0])),
-		Math.max(Math.abs(this.minBounds[1]), Math.abs(this.maxBounds[1])),
-		Math.max(Math.abs(this.minBounds[2]), Math.abs(this.maxBounds[2]))
-	);
-	var hitBoxCoord = vec3.multiply(highestCoord, highestCoord, this.roomUnitSize);
-	var hitBoxRadius = vec3.length(hitBoxCoord);
-	
-	var self = this;
-	var positionRelativeToBody = vec3.create()
-	this.world.spaceContent.forEachCollidableBody(function(body) {
-		var hitBoxDistanceFromBody = vec3.distance(body.position, self._position) - hitBoxRadius;
-		if(hitBoxDistanceFromBody < body.orbitRadius) {
-			// Moving the spaceship to the orbit
-			vec3.subtract(positionRelativeToBody, body.position, self._position);
-			vec3.scale(positionRelativeToBody, positionRelativeToBody, (body.orbitRadius / hitBoxDistanceFromBody) - 1);
-			vec3.subtract(self._position, self._position, positionRelativeToBody);
-			
-			// Stopping the spaceship
-			self.linearSpeed = 0;
-		}
-	});
-};
-
 SpaceShip.prototype.updateAcceleration = function() {
 	// Objects are loading, so we can't update this now
 	if(this.minBounds == null || this.maxBounds == null) return;
 	
-	// Bug with middle when we have a window to the "rightest" corner
-	var middleXY = [
-		((this.maxBounds[0] - this.minBounds[0]) / 2) + this.minBounds[0],
-		((this.maxBounds[1] - this.minBounds[1]) / 2) + this.minBounds[1]
-	];
-	vec3.set(this.rotationSpeed, 0, 0, 0);
+	this._mass = 0;
+	vec3.set(this._center, 0, 0, 0);
+	vec3.set(this._inertia, 0, 0, 0);
+	vec3.set(this._force, 0, 0, 0);
+	vec3.set(this._torque, 0, 0, 0);
+	this._maxSpeed = 0;
+	
+	// Center of a building in the grid
+	var centers = {};
+	for(var k in this.entities) {
+		var entity = this.entities[k];
+		var center = vec3.fromValues(
+			entity.gridPosition[0] + (entity.gridSize[0] - 1) / 2,
+			entity.gridPosition[1] + (entity.gridSize[1] - 1) / 2,
+			entity.gridPosition[2] + (entity.gridSize[2] - 1) / 2
+		);
+		var mass = this._getBuildingMass(entity);
+		
+		centers[k] = center;
+		this._mass += mass;
+		vec3.scaleAndAdd(this._center, this._center, center, mass);
+	}
+	if(this._mass <= 0) return;
+	vec3.scale(this._center, this._center, 1 / this._mass);
+	
+	// Each building is a uniform box
+	var d = vec3.create();
+	for(var k in this.entities) {
+		var entity = this.entities[k];
+		var size = entity.gridSize;
+		var mass = this._getBuildingMass(entity);
+		vec3.subtract(d, centers[k], this._center);
+		for(var axis = 0 ; axis < 3 ; axis++) {
+			var a = (axis + 1) % 3, b = (axis + 2) % 3;
+			this._inertia[axis] += mass * (d[a] * d[a] + d[b] * d[b] + (size[a] * size[a] + size[b] * size[b]) / 12);
+		}
+	}
 	
-	this._linearAcceleration = 0;
-	this._linearMaxSpeed = 0;
+	var force = vec3.create();
+	var torque = vec3.create();
 	for(var k in this.entities) {
 		var entity = this.entities[k];
 		if(entity.type.exertThrust && entity.isBuilt && entity.isEnabled) {
-			var positionX = entity.gridPosition[0] + (entity.gridSize[0] / 2 - 0.5);
-			var positionY = entity.gridPosition[1] + (entity.gridSize[1] / 2 - 0.5);
-			
-			var maxState = entity.type.maxState;
-			var state = entity.getState();
 			var size = entity.gridSize;
-			var sizeMultiplicator = size[0] * size[1] * size[2];
+			var volume = size[0] * size[1] * size[2];
+			
+			// Propellers push the spaceship towards their front (-z)
+			vec3.transformQuat(force, SpaceShip.front, entity.gridRotation);
+			vec3.scale(force, force, entity.getState() * volume * this.thrustPerPropellerUnit);
+			vec3.add(this._force, this._force, force);
 			
-			this.rotationSpeed[1] += (middleXY[0] - positionX) * state * sizeMultiplicator;
-			this.rotationSpeed[0] -= (middleXY[1] - positionY) * state * sizeMultiplicator;
+			vec3.subtract(d, centers[k], this._center);
+			vec3.cross(torque, d, force);
+			vec3.add(this._torque, this._torque, torque);
 			
-			this._linearAcceleration += state * sizeMultiplicator;
-			this._linearMaxSpeed += this.maxSpeedPerPropellerUnit * maxState * sizeMultiplicator;
+			this._maxSpeed += this.maxSpeedPerPropellerUnit * entity.type.maxState * volume;
 		}
 	}
 };
 
+/**
+ * @param Building A building of the spaceship
+ * @return float The mass of the building with its items
+ */
+SpaceShip.prototype._getBuildingMass = function(building) {
+	var size = building.gridSize;
+	return size[0] * size[1] * size[2] * this.buildingUnitMass + building.items.length * this.itemMass;
+};
+
 /**
  * Removes all it's content from the world
  */
@@ -393,4 +455,7 @@ SpaceShip.prototype.destroy = function() {
 	this.world.remove(entitiesToRemove);
 };
 
+SpaceShip.integrationStep = 0.1; // Static, longest step of the integration of the movements (seconds), like the server
+SpaceShip.front = vec3.fromValues(0, 0, -1); // Static, front of the spaceships and of the propellers, before their rotation
+
 // TODO door bugs with transparency (+ window blinking on chrome when opening or closing) ?!?
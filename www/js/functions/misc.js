@@ -41,18 +41,18 @@ function radToDeg(radians) {
 }
 
 /**
- * Returns the euler rotation from a Quaternion
+ * Returns the euler rotation from a Quaternion (inverse of eulerToQuat)
  * @param quat The rotation quaternion
- * @return vec3 The euler angles
+ * @return vec3 The euler angles (degrees)
  */
-/*function quatToEuler(q) {
+function quatToEuler(q) {
 	var x = q[0], y = q[1], z = q[2], w = q[3];
 	return vec3.fromValues(
-		Math.atan2( 2 * (y * z + w * x), w * w - x * x - y * y + z * z),
-		Math.asin (-2 * (x * z - w * y)),
-		Math.atan2( 2 * (x * y + w * z), w * w + x * x - y * y - z * z)
+		radToDeg(Math.atan2(2 * (x * w - y * z), 1 - 2 * (x * x + y * y))),
+		radToDeg(Math.asin(Math.max(-1, Math.min(1, 2 * (x * z + y * w))))),
+		radToDeg(Math.atan2(2 * (z * w - x * y), 1 - 2 * (y * y + z * z)))
 	);
-}*/
+}
 
 /**
  * Returns the quaternion rotation from a vec3 (euler)
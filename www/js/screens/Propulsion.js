@@ -282,7 +282,11 @@ Screens.Propulsion = {
 		this._serverUpdateSelectedContent = controlScreen.selectedContent;
 		this._serverUpdateTimeout = setTimeout(function() {
 			// Timeout to avoid sending an update at each percent change
-			controlScreen.world.server.sendMessage("updatePropellers", {"id": controlScreen.selectedContent, "power": newPower});
+			controlScreen.world.server.sendMessage("updatePropellers", {
+				"id": controlScreen.selectedContent,
+				"power": newPower,
+				"sequence": controlScreen.world.server.nextInputSequence()
+			});
 			self._serverUpdateTimeout = null;
 		}, 500);
 	}